@@ -0,0 +1,111 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mandelsoft/mdgen/config"
+	"github.com/mandelsoft/mdgen/tree"
+	"github.com/mandelsoft/mdgen/utils"
+)
+
+var checkCmd = &Command{
+	Name:  "check",
+	Args:  "[<source> [<target>]]",
+	Short: "verify that a generated markdown tree is up to date",
+	Long: `
+Generate the markdown tree for the source folder in memory and compare it with
+the content of the target folder without writing anything. Every document or
+resource, which would be added or changed, and every stale generated file is
+reported. If the target tree is not up to date, the command fails.
+`,
+	Setup: func(fs *flag.FlagSet) func(args []string) error {
+		var flags CommonFlags
+		var diff bool
+
+		flags.AddFlags(fs)
+		flags.AddIndexFlags(fs)
+		fs.BoolVar(&diff, "diff", false, "print a unified diff for every changed document")
+
+		return func(args []string) error {
+			if len(args) > 2 {
+				return usageErrorf("at most source and target folder expected")
+			}
+			opts, src, dst, err := flags.Options(args)
+			if err != nil {
+				return err
+			}
+			if opts.Format != config.FORMAT_MARKDOWN {
+				return fmt.Errorf("check is only supported for %s output", config.FORMAT_MARKDOWN)
+			}
+			tw, diffs, err := Check(src, dst, opts)
+			if err != nil {
+				return flags.Report(err)
+			}
+			for _, d := range diffs {
+				if diff {
+					err = Diff(os.Stdout, tw, d)
+					if err != nil {
+						return err
+					}
+				} else {
+					fmt.Printf("%-7s %s\n", d.Kind, d.Path)
+				}
+			}
+			if len(diffs) > 0 {
+				return fmt.Errorf("target tree %s is not up to date (%d differences)", dst, len(diffs))
+			}
+			return nil
+		}
+	},
+}
+
+// Check generates the target tree for the given source folder in memory
+// and compares it with the actual target folder.
+func Check(src, dst string, opts Options) (tree.MemoryTreeWriter, []tree.Difference, error) {
+	tw := tree.NewMemoryTreeWriter(dst)
+	err := Emit(src, tw, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	diffs, err := tree.Compare(tw)
+	return tw, diffs, err
+}
+
+// Diff prints a unified diff for a difference found by Check.
+// Resources are not diffed, only reported.
+func Diff(w io.Writer, tw tree.MemoryTreeWriter, d tree.Difference) error {
+	var old, new []byte
+
+	oldname, newname := "a/"+d.Path, "b/"+d.Path
+	if d.Kind != tree.DIFF_ADDED {
+		data, err := os.ReadFile(d.Path)
+		if err != nil {
+			return err
+		}
+		old = data
+	} else {
+		oldname = "/dev/null"
+	}
+	if d.Kind != tree.DIFF_STALE {
+		new, _ = tw.Content(d.Path)
+	} else {
+		newname = "/dev/null"
+	}
+
+	if !strings.HasSuffix(d.Path, ".md") {
+		fmt.Fprintf(w, "%s resource %s differs\n", d.Kind, d.Path)
+		return nil
+	}
+	_, err := io.WriteString(w, utils.UnifiedDiff(oldname, newname, string(old), string(new), utils.DefaultDiffContext))
+	return err
+}
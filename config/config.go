@@ -0,0 +1,209 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mandelsoft/mdgen/render"
+	"github.com/mandelsoft/mdgen/utils"
+)
+
+// CONFIG_FILE is the name of the project configuration file
+// looked up in the source root.
+const CONFIG_FILE = "mdgen.yaml"
+
+const (
+	// FORMAT_MARKDOWN generates a tree of markdown files (default).
+	FORMAT_MARKDOWN = "markdown"
+	// FORMAT_HTML generates a static HTML site.
+	FORMAT_HTML = "html"
+	// FORMAT_WIKI generates the pages of a GitHub wiki.
+	FORMAT_WIKI = "wiki"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{FORMAT_MARKDOWN, FORMAT_HTML, FORMAT_WIKI}
+
+const (
+	// END_STYLE_KEEP keeps the spelling of end tokens when formatting sources (default).
+	END_STYLE_KEEP = "keep"
+	// END_STYLE_SHORT uses the generic end token.
+	END_STYLE_SHORT = "short"
+	// END_STYLE_LONG uses the statement specific end tokens.
+	END_STYLE_LONG = "long"
+)
+
+// EndStyles lists the supported end token styles for formatting sources.
+var EndStyles = []string{END_STYLE_KEEP, END_STYLE_SHORT, END_STYLE_LONG}
+
+// Config describes the project configuration.
+// Relative paths are interpreted relative to the folder
+// containing the configuration file.
+type Config struct {
+	Source string `yaml:"source,omitempty"`
+	Target string `yaml:"target,omitempty"`
+	Copy   bool   `yaml:"copy,omitempty"`
+	Prune  bool   `yaml:"prune,omitempty"`
+	// Single enables the generation of a single document
+	// for every root document.
+	Single bool `yaml:"single,omitempty"`
+	// FrontMatter enables the generation of YAML front matter
+	// for the generated documents.
+	FrontMatter bool `yaml:"frontmatter,omitempty"`
+	// Inventory enables the generation of a reference inventory
+	// into the target folder, which can be imported by other projects.
+	Inventory bool `yaml:"inventory,omitempty"`
+	// Search enables the generation of a search index
+	// into the target folder.
+	Search bool `yaml:"search,omitempty"`
+	// Inventories describes the reference inventories of other
+	// projects imported by a project prefix used for links and terms.
+	Inventories map[string]InventoryImport `yaml:"inventories,omitempty"`
+
+	// NumberRanges describes default number ranges used if not
+	// declared by a root document. The entries use the syntax of the arguments
+	// of the numberrange statement.
+	NumberRanges []string `yaml:"numberranges,omitempty"`
+	// Values describes predefined values, which can be used by
+	// the value statement in all documents.
+	Values map[string]string `yaml:"values,omitempty"`
+
+	// Include and Exclude describe path patterns for source files
+	// (relative to the source folder), which should be considered.
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+
+	// Flavour is the name of a registered renderer (see render.Names).
+	Flavour string `yaml:"flavour,omitempty"`
+	Format  string `yaml:"format,omitempty"`
+
+	// EndStyle describes the spelling of end tokens
+	// used by the fmt command.
+	EndStyle string `yaml:"endstyle,omitempty"`
+}
+
+// InventoryImport describes the reference inventory of another project.
+// URL is the location of the published generated tree of the project.
+// The inventory is read from Inventory, a file path or URL (default:
+// the inventory file below URL).
+type InventoryImport struct {
+	URL       string `yaml:"url"`
+	Inventory string `yaml:"inventory,omitempty"`
+}
+
+// Read reads a configuration file. Relative source and
+// target folders are resolved relative to the folder of the
+// configuration file.
+func Read(file string, fss ...vfs.FileSystem) (*Config, error) {
+	fs := utils.OptionalDefaulted(osfs.New(), fss...)
+
+	data, err := vfs.ReadFile(fs, file)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	dir := path.Dir(file)
+	if cfg.Source != "" && !path.IsAbs(cfg.Source) {
+		cfg.Source = path.Join(dir, cfg.Source)
+	}
+	if cfg.Target != "" && !path.IsAbs(cfg.Target) {
+		cfg.Target = path.Join(dir, cfg.Target)
+	}
+	for n, i := range cfg.Inventories {
+		if i.Inventory != "" && !utils.IsURL(i.Inventory) && !path.IsAbs(i.Inventory) {
+			i.Inventory = path.Join(dir, i.Inventory)
+			cfg.Inventories[n] = i
+		}
+	}
+	return cfg, nil
+}
+
+// Parse parses and validates a configuration.
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	err := dec.Decode(&cfg)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return &cfg, cfg.Validate()
+}
+
+// Validate checks the configuration for consistency.
+func (c *Config) Validate() error {
+	if err := check("flavour", c.Flavour, render.Names()); err != nil {
+		return err
+	}
+	if err := check("format", c.Format, Formats); err != nil {
+		return err
+	}
+	if err := check("end style", c.EndStyle, EndStyles); err != nil {
+		return err
+	}
+	for _, n := range utils.StringMapKeys(c.Inventories) {
+		if p, _ := utils.SplitProjectPrefix(n + ":"); p != n {
+			return fmt.Errorf("invalid project prefix %q", n)
+		}
+		if c.Inventories[n].URL == "" {
+			return fmt.Errorf("url required for inventory %q", n)
+		}
+	}
+	for _, p := range append(append([]string{}, c.Include...), c.Exclude...) {
+		if err := utils.CheckPathPattern(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func check(kind, value string, values []string) error {
+	if value == "" {
+		return nil
+	}
+	for _, v := range values {
+		if v == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown %s %q (use one of %s)", kind, value, strings.Join(values, ", "))
+}
+
+// Lookup looks for a configuration file in the given folders and
+// returns the first one found. For a regular file the folder containing
+// the file is used. If no file is found, an empty string is returned.
+func Lookup(dirs []string, fss ...vfs.FileSystem) (string, error) {
+	fs := utils.OptionalDefaulted(osfs.New(), fss...)
+	for _, d := range dirs {
+		if ok, _ := vfs.IsFile(fs, d); ok {
+			d = path.Dir(d)
+		}
+		p := path.Join(d, CONFIG_FILE)
+		ok, err := vfs.FileExists(fs, p)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return p, nil
+		}
+	}
+	return "", nil
+}
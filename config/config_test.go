@@ -0,0 +1,122 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package config_test
+
+import (
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/mdgen/config"
+	"github.com/mandelsoft/mdgen/render"
+)
+
+var _ = Describe("config", func() {
+	var fs vfs.FileSystem
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(fs.MkdirAll("/project/src", 0755)).To(Succeed())
+	})
+
+	It("parses a configuration", func() {
+		cfg, err := config.Parse([]byte(`
+source: src
+target: doc
+copy: true
+single: true
+frontmatter: true
+inventory: true
+search: true
+inventories:
+  platform:
+    url: https://example.com/platform/
+numberranges:
+- "section:A."
+values:
+  product: mdgen
+exclude:
+- "drafts/**"
+flavour: github
+endstyle: long
+`))
+		Expect(err).To(Succeed())
+		Expect(cfg).To(Equal(&config.Config{
+			Source:      "src",
+			Target:      "doc",
+			Copy:        true,
+			Single:      true,
+			FrontMatter: true,
+			Inventory:   true,
+			Search:      true,
+			Inventories: map[string]config.InventoryImport{
+				"platform": {URL: "https://example.com/platform/"},
+			},
+			NumberRanges: []string{"section:A."},
+			Values:       map[string]string{"product": "mdgen"},
+			Exclude:      []string{"drafts/**"},
+			Flavour:      render.FLAVOUR_GITHUB,
+			EndStyle:     config.END_STYLE_LONG,
+		}))
+	})
+
+	It("accepts an empty configuration", func() {
+		cfg, err := config.Parse(nil)
+		Expect(err).To(Succeed())
+		Expect(cfg).To(Equal(&config.Config{}))
+	})
+
+	It("rejects unknown fields", func() {
+		_, err := config.Parse([]byte("sources: src\n"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects invalid settings", func() {
+		_, err := config.Parse([]byte("flavour: other\n"))
+		Expect(err).To(MatchError(`unknown flavour "other" (use one of commonmark, github)`))
+		_, err = config.Parse([]byte("format: pdf\n"))
+		Expect(err).To(MatchError(`unknown format "pdf" (use one of markdown, html, wiki)`))
+		_, err = config.Parse([]byte("endstyle: mixed\n"))
+		Expect(err).To(MatchError(`unknown end style "mixed" (use one of keep, short, long)`))
+		_, err = config.Parse([]byte("inventories:\n  other:\n    inventory: inv.json\n"))
+		Expect(err).To(MatchError(`url required for inventory "other"`))
+		_, err = config.Parse([]byte("inventories:\n  other/x:\n    url: http://x\n"))
+		Expect(err).To(MatchError(`invalid project prefix "other/x"`))
+		_, err = config.Parse([]byte("include: [\"a/[\"]\n"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("reads and resolves relative folders", func() {
+		Expect(vfs.WriteFile(fs, "/project/"+config.CONFIG_FILE, []byte("source: src\ntarget: /doc\ninventories:\n  platform:\n    url: https://example.com\n    inventory: ../platform/doc/mdgen-inventory.json\n"), 0644)).To(Succeed())
+
+		file, err := config.Lookup([]string{"/project/src", "/project"}, fs)
+		Expect(err).To(Succeed())
+		Expect(file).To(Equal("/project/" + config.CONFIG_FILE))
+
+		cfg, err := config.Read(file, fs)
+		Expect(err).To(Succeed())
+		Expect(cfg.Source).To(Equal("/project/src"))
+		Expect(cfg.Target).To(Equal("/doc"))
+		Expect(cfg.Inventories["platform"].Inventory).To(Equal("/platform/doc/mdgen-inventory.json"))
+	})
+
+	It("looks up the configuration for a source file", func() {
+		Expect(vfs.WriteFile(fs, "/project/"+config.CONFIG_FILE, []byte("source: src\n"), 0644)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/project/one.mdg", []byte("text\n"), 0644)).To(Succeed())
+
+		file, err := config.Lookup([]string{"/project/one.mdg"}, fs)
+		Expect(err).To(Succeed())
+		Expect(file).To(Equal("/project/" + config.CONFIG_FILE))
+	})
+
+	It("finds no configuration", func() {
+		file, err := config.Lookup([]string{"/project/src"}, fs)
+		Expect(err).To(Succeed())
+		Expect(file).To(Equal(""))
+	})
+})
@@ -0,0 +1,223 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package diagnostics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SEVERITY_ERROR   Severity = "error"
+	SEVERITY_WARNING Severity = "warning"
+)
+
+// Codes describe the processing phase a diagnostic is found in.
+// CODE_REFERENCES is used for problems found during the label
+// generation and link resolution.
+const (
+	CODE_ERROR      = "error"
+	CODE_SYNTAX     = "syntax"
+	CODE_DEFINITION = "definition"
+	CODE_STRUCTURE  = "structure"
+	CODE_REFERENCES = "references"
+	CODE_RESOLUTION = "resolution"
+	CODE_UNRESOLVED = "unresolved"
+	CODE_EMIT       = "emit"
+)
+
+// Diagnostic describes a problem found for a source location.
+// It is used as error and provides the same error text as an
+// error created for a scanner location.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Code     string   `json:"code,omitempty"`
+	Message  string   `json:"message"`
+
+	cause error
+}
+
+var _ error = (*Diagnostic)(nil)
+
+// New creates an error diagnostic for a source location.
+func New(file string, line, column int, msg string, args ...interface{}) *Diagnostic {
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	return &Diagnostic{
+		Severity: SEVERITY_ERROR,
+		File:     file,
+		Line:     line,
+		Column:   column,
+		Message:  msg,
+	}
+}
+
+// Wrap creates an error diagnostic for a source location
+// describing the given error.
+func Wrap(file string, line, column int, err error) *Diagnostic {
+	d := New(file, line, column, "%s", err.Error())
+	d.cause = err
+	return d
+}
+
+func (d *Diagnostic) Location() string {
+	if d.Line == 0 {
+		return d.File
+	}
+	if d.File == "" {
+		return fmt.Sprintf("line %d, column %d", d.Line, d.Column)
+	}
+	return fmt.Sprintf("%s: line %d, column %d", d.File, d.Line, d.Column)
+}
+
+func (d *Diagnostic) Error() string {
+	loc := d.Location()
+	if loc == "" {
+		return d.Message
+	}
+	return loc + ": " + d.Message
+}
+
+func (d *Diagnostic) Unwrap() error {
+	return d.cause
+}
+
+func (d *Diagnostic) setCode(code string) {
+	if d.Code == "" {
+		d.Code = code
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Diagnostics is a list of diagnostics, which can be used as error.
+type Diagnostics []*Diagnostic
+
+var _ error = Diagnostics(nil)
+
+// Add adds the diagnostics described by an error. Errors
+// not providing diagnostics are added as diagnostic without location
+// using the given code.
+func (l *Diagnostics) Add(err error, code string) {
+	if err == nil {
+		return
+	}
+	var list Diagnostics
+	if errors.As(err, &list) {
+		for _, d := range list {
+			l.add(d, code)
+		}
+		return
+	}
+	var d *Diagnostic
+	if errors.As(err, &d) {
+		l.add(d, code)
+		return
+	}
+	l.add(&Diagnostic{Severity: SEVERITY_ERROR, Code: code, Message: err.Error(), cause: err}, code)
+}
+
+func (l *Diagnostics) add(d *Diagnostic, code string) {
+	d.setCode(code)
+	*l = append(*l, d)
+}
+
+// Error provides the error text of all contained diagnostics
+// separated by newlines.
+func (l Diagnostics) Error() string {
+	var msgs []string
+	for _, d := range l {
+		msgs = append(msgs, d.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Err returns the diagnostics as error or nil, if there are
+// no diagnostics.
+func (l Diagnostics) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// Sort orders the diagnostics by their source location.
+func (l Diagnostics) Sort() {
+	sort.SliceStable(l, func(i, j int) bool {
+		a, b := l[i], l[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// HasErrors checks whether there is a diagnostic with error severity.
+func (l Diagnostics) HasErrors() bool {
+	for _, d := range l {
+		if d.Severity == SEVERITY_ERROR {
+			return true
+		}
+	}
+	return false
+}
+
+// List provides the diagnostics described by an error.
+func List(err error) Diagnostics {
+	var list Diagnostics
+	list.Add(err, CODE_ERROR)
+	return list
+}
+
+// WithCode sets the given code for all diagnostics
+// of the given error, which do not have a code, yet. An error
+// not providing diagnostics is converted into a diagnostic
+// without location.
+func WithCode(err error, code string) error {
+	if err == nil {
+		return nil
+	}
+	var list Diagnostics
+	if errors.As(err, &list) {
+		for _, d := range list {
+			d.setCode(code)
+		}
+		return err
+	}
+	var d *Diagnostic
+	if errors.As(err, &d) {
+		d.setCode(code)
+		return err
+	}
+	return &Diagnostic{Severity: SEVERITY_ERROR, Code: code, Message: err.Error(), cause: err}
+}
+
+// WriteJSON writes the diagnostics as JSON array.
+func WriteJSON(w io.Writer, list Diagnostics) error {
+	if list == nil {
+		list = Diagnostics{}
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
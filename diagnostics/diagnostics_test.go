@@ -0,0 +1,60 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package diagnostics_test
+
+import (
+	"bytes"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/mdgen/diagnostics"
+)
+
+var _ = Describe("diagnostics", func() {
+	It("formats like a location error", func() {
+		Expect(diagnostics.New("doc.mdg", 2, 5, "unknown %q", "x").Error()).To(Equal(`doc.mdg: line 2, column 5: unknown "x"`))
+		Expect(diagnostics.New("doc.mdg", 0, 0, "failed").Error()).To(Equal(`doc.mdg: failed`))
+	})
+
+	It("collects wrapped diagnostics", func() {
+		var list diagnostics.Diagnostics
+		list.Add(fmt.Errorf("src: %w", diagnostics.New("b.mdg", 1, 1, "b")), diagnostics.CODE_SYNTAX)
+		list.Add(diagnostics.Diagnostics{diagnostics.New("a.mdg", 3, 1, "a")}, diagnostics.CODE_RESOLUTION)
+		list.Add(fmt.Errorf("plain"), diagnostics.CODE_EMIT)
+		list.Sort()
+
+		Expect(list.Error()).To(Equal("plain\na.mdg: line 3, column 1: a\nb.mdg: line 1, column 1: b"))
+		Expect(list[0].Code).To(Equal(diagnostics.CODE_EMIT))
+		Expect(list[1].Code).To(Equal(diagnostics.CODE_RESOLUTION))
+		Expect(list[2].Code).To(Equal(diagnostics.CODE_SYNTAX))
+	})
+
+	It("keeps codes", func() {
+		err := diagnostics.WithCode(diagnostics.New("a.mdg", 1, 1, "a"), diagnostics.CODE_SYNTAX)
+		err = diagnostics.WithCode(err, diagnostics.CODE_EMIT)
+		Expect(diagnostics.List(err)[0].Code).To(Equal(diagnostics.CODE_SYNTAX))
+	})
+
+	It("writes json", func() {
+		buf := &bytes.Buffer{}
+		err := diagnostics.WithCode(diagnostics.New("a.mdg", 1, 2, "a"), diagnostics.CODE_SYNTAX)
+		Expect(diagnostics.WriteJSON(buf, diagnostics.List(err))).To(Succeed())
+		Expect(buf.String()).To(Equal(`[
+  {
+    "severity": "error",
+    "file": "a.mdg",
+    "line": 1,
+    "column": 2,
+    "code": "syntax",
+    "message": "a"
+  }
+]
+`))
+	})
+})
@@ -0,0 +1,62 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+)
+
+var exportCmd = &Command{
+	Name:  "export",
+	Args:  "--json [<source>]",
+	Short: "export the resolved document model of a source tree",
+	Long: `
+Resolve the source tree and export the resolved document model. It describes
+the documents with their source and target paths and their position in the
+document structure, the sections with labels, titles and anchors, the
+reference index, the terms with their singular and plural forms and glossary
+text, the text modules with their parameters and the number ranges of the root
+documents with their label rules.
+
+The model is written to stdout. Supported formats: JSON (--json).
+`,
+	Setup: func(fs *flag.FlagSet) func(args []string) error {
+		var flags CommonFlags
+		var asJSON bool
+
+		flags.AddFlags(fs)
+		fs.BoolVar(&asJSON, "json", false, "export the model as JSON")
+
+		return func(args []string) error {
+			if len(args) > 1 {
+				return usageErrorf("at most a source folder expected")
+			}
+			if !asJSON {
+				return usageErrorf("export format required (--json)")
+			}
+			opts, src, _, err := flags.Options(args)
+			if err != nil {
+				return err
+			}
+			// the model must not depend on resources copied into a target tree
+			opts.Copy = false
+			t, err := Load(src, opts)
+			if err != nil {
+				return flags.Report(err)
+			}
+			m, err := t.Model()
+			if err != nil {
+				return flags.Report(err)
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(m)
+		}
+	},
+}
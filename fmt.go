@@ -0,0 +1,127 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/mdgen/config"
+	"github.com/mandelsoft/mdgen/diagnostics"
+	"github.com/mandelsoft/mdgen/formatter"
+	"github.com/mandelsoft/mdgen/tree"
+	"github.com/mandelsoft/mdgen/utils"
+)
+
+var fmtCmd = &Command{
+	Name:  "fmt",
+	Args:  "[<source>]",
+	Short: "rewrite the source documents into a canonical layout",
+	Long: `
+Rewrite the source documents of a source folder (or a single source file) into
+a canonical layout. The spacing inside statements is normalized, end tokens
+are spelled according to the end style, arg and param statements starting a
+line are indented and the closing braces of flagged sectionref statements on
+consecutive lines are aligned. All other content is preserved. Documents with
+syntax errors are not changed.
+
+End styles: keep (default) keeps the end tokens, short uses {{end}} and long
+uses {{end<statement>}}. The style can be configured with the endstyle field
+of the project configuration.
+`,
+	Setup: func(fs *flag.FlagSet) func(args []string) error {
+		var flags CommonFlags
+		var end string
+		var list bool
+
+		flags.AddFlags(fs)
+		fs.StringVar(&end, "end", "", "spelling of end tokens ("+strings.Join(config.EndStyles, ", ")+")")
+		fs.BoolVar(&list, "l", false, "only list the files whose formatting differs")
+
+		return func(args []string) error {
+			if len(args) > 1 {
+				return usageErrorf("at most a source folder expected")
+			}
+			opts, src, _, err := flags.Options(args)
+			if err != nil {
+				return err
+			}
+			if end != "" {
+				opts.EndStyle = end
+			}
+			err = (&config.Config{EndStyle: opts.EndStyle}).Validate()
+			if err != nil {
+				return usageError{err.Error()}
+			}
+			files, err := Format(src, opts, !list)
+			if list {
+				for _, f := range files {
+					fmt.Println(f)
+				}
+			}
+			if err != nil {
+				return flags.Report(err)
+			}
+			if list && len(files) > 0 {
+				return fmt.Errorf("%d source files are not formatted", len(files))
+			}
+			return nil
+		}
+	},
+}
+
+// Format formats the source documents of the given source folder and
+// provides the files whose formatting differs. If write is set, these
+// files are rewritten. Files with errors are reported as diagnostics.
+func Format(src string, opts Options, write bool) ([]string, error) {
+	fs := utils.OptionalDefaulted(osfs.New(), opts.FS)
+	files, err := tree.SourceFiles(src, tree.ScanOptions{
+		Include: opts.Include,
+		Exclude: opts.Exclude,
+	}, fs)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	var list diagnostics.Diagnostics
+	for _, f := range files {
+		fi, err := fs.Stat(f)
+		if err != nil {
+			list.Add(err, diagnostics.CODE_ERROR)
+			continue
+		}
+		data, err := vfs.ReadFile(fs, f)
+		if err != nil {
+			list.Add(err, diagnostics.CODE_ERROR)
+			continue
+		}
+		result, err := formatter.Format(f, data, formatter.Options{End: formatter.EndStyle(opts.EndStyle)})
+		if err != nil {
+			list.Add(err, diagnostics.CODE_SYNTAX)
+			continue
+		}
+		if bytes.Equal(data, result) {
+			continue
+		}
+		changed = append(changed, f)
+		if write {
+			opts.Logger.Infof("formatting %s", f)
+			err = vfs.WriteFile(fs, f, result, fi.Mode())
+			if err != nil {
+				list.Add(err, diagnostics.CODE_ERROR)
+			}
+		}
+	}
+	list.Sort()
+	return changed, list.Err()
+}
@@ -0,0 +1,259 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mandelsoft/mdgen/scanner"
+)
+
+// EndStyle describes the spelling of the end tokens of statements.
+type EndStyle string
+
+const (
+	// END_KEEP keeps the end tokens as they are (default).
+	END_KEEP EndStyle = "keep"
+	// END_SHORT uses the generic {{end}} token.
+	END_SHORT EndStyle = "short"
+	// END_LONG uses the statement specific {{end<statement>}} tokens.
+	END_LONG EndStyle = "long"
+)
+
+// INDENT is used to indent the arg and param statements
+// of text modules.
+const INDENT = "  "
+
+// Options describes the formatting options.
+type Options struct {
+	End EndStyle
+}
+
+// Format rewrites the source of a document into the canonical layout:
+//   - the spacing inside statements is normalized
+//   - end tokens are spelled according to the end style
+//   - arg and param statements starting a line are indented
+//   - the closing braces of flagged sectionref statements used
+//     on consecutive lines are aligned
+//
+// All other content is preserved byte-for-byte. Documents with
+// syntax errors are not formatted.
+func Format(source string, data []byte, opts Options) ([]byte, error) {
+	switch opts.End {
+	case "", END_KEEP, END_SHORT, END_LONG:
+	default:
+		return nil, fmt.Errorf("invalid end style %q", opts.End)
+	}
+	elems, err := scanner.ParseRaw(source, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	f := &formatter{opts: opts}
+	for i, e := range elems {
+		if e.IsText() {
+			f.buf.WriteString(e.Raw())
+			continue
+		}
+		var next scanner.Element
+		if i+1 < len(elems) {
+			next = elems[i+1]
+		}
+		f.statement(e, next)
+	}
+	f.align()
+
+	result := f.buf.Bytes()
+	if err := verify(source, elems, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type formatter struct {
+	opts Options
+	buf  bytes.Buffer
+
+	// closings are the positions of the closing braces
+	// of flagged sectionref statements.
+	closings []closing
+}
+
+type closing struct {
+	line   int
+	column int
+	pos    int
+}
+
+func (f *formatter) statement(e scanner.Element, next scanner.Element) {
+	f.buf.WriteString(e.RawPrefix())
+
+	token, suffix := f.end(e, next)
+	if (token == "arg" || token == "param") && e.RawPrefix() == "" {
+		f.indent()
+	}
+	start := f.buf.Len()
+	f.buf.WriteString("{{")
+	if e.IsFlagged() {
+		f.buf.WriteString("*")
+	}
+	f.buf.WriteString(token)
+	tags := e.RawTags()
+	for _, t := range tags {
+		f.buf.WriteString(" ")
+		f.buf.WriteString(t)
+	}
+	if token == "sectionref" && e.IsFlagged() {
+		f.closings = append(f.closings, f.closing(start))
+	}
+	if len(tags) > 0 && strings.HasSuffix(tags[len(tags)-1], "}") {
+		// keep the closing braces separated from the tag
+		f.buf.WriteString(" ")
+	}
+	f.buf.WriteString("}}")
+	f.buf.WriteString(suffix)
+}
+
+// end determines the token and the raw suffix used for a token
+// according to the end style. The spelling is only changed if the
+// handling of a following newline can be preserved.
+func (f *formatter) end(e scanner.Element, next scanner.Element) (string, string) {
+	token, suffix := e.Token(), e.RawSuffix()
+	closes := e.Closes()
+	if closes == "" || (token != "end" && token != "end"+closes) {
+		return token, suffix
+	}
+
+	var eff string
+	switch f.opts.End {
+	case END_SHORT:
+		eff = "end"
+	case END_LONG:
+		eff = "end" + closes
+	default:
+		return token, suffix
+	}
+	if eff == token {
+		return token, suffix
+	}
+
+	skip := scanner.SkipNewline.Skips(eff, e.Location().Column())
+	switch suffix {
+	case "\n":
+		if !skip {
+			return token, suffix
+		}
+	case "":
+		if skip && next != nil && strings.HasPrefix(next.RawPrefix()+next.Raw(), "\n") {
+			// keep the newline
+			suffix = "\\"
+		}
+	}
+	return eff, suffix
+}
+
+// indent replaces the white space preceding a statement
+// at the beginning of a line by INDENT.
+func (f *formatter) indent() {
+	data := f.buf.Bytes()
+	trimmed := bytes.TrimRight(data, " \t")
+	if len(trimmed) > 0 && trimmed[len(trimmed)-1] != '\n' {
+		return
+	}
+	f.buf.Truncate(len(trimmed))
+	f.buf.WriteString(INDENT)
+}
+
+func (f *formatter) closing(start int) closing {
+	data := f.buf.Bytes()
+	sol := bytes.LastIndexByte(data[:start], '\n') + 1
+	return closing{
+		line:   bytes.Count(data[:start], []byte("\n")),
+		column: utf8.RuneCount(data[sol:start]),
+		pos:    f.buf.Len(),
+	}
+}
+
+// align aligns the closing braces of flagged sectionref
+// statements starting at the same column of consecutive lines.
+func (f *formatter) align() {
+	var pads []int
+	for i := 0; i < len(f.closings); {
+		j := i + 1
+		for j < len(f.closings) &&
+			f.closings[j].line == f.closings[j-1].line+1 &&
+			f.closings[j].column == f.closings[i].column {
+			j++
+		}
+		group := f.closings[i:j]
+		max := 0
+		for _, c := range group {
+			if w := f.width(c); w > max {
+				max = w
+			}
+		}
+		for _, c := range group {
+			pads = append(pads, max-f.width(c))
+		}
+		i = j
+	}
+
+	data := f.buf.Bytes()
+	var result bytes.Buffer
+	last := 0
+	for i, c := range f.closings {
+		result.Write(data[last:c.pos])
+		result.WriteString(strings.Repeat(" ", pads[i]))
+		last = c.pos
+	}
+	result.Write(data[last:])
+	f.buf = result
+}
+
+// width provides the width of a statement up to its closing braces.
+func (f *formatter) width(c closing) int {
+	data := f.buf.Bytes()
+	sol := bytes.LastIndexByte(data[:c.pos], '\n') + 1
+	return utf8.RuneCount(data[sol:c.pos]) - c.column
+}
+
+// verify checks that the formatted document describes
+// the same statements as the original one.
+func verify(source string, elems []scanner.Element, data []byte) error {
+	formatted, err := scanner.ParseRaw(source, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("formatting failed: %w", err)
+	}
+	orig := statements(elems)
+	result := statements(formatted)
+	if len(orig) != len(result) {
+		return fmt.Errorf("%s: formatting changed the number of statements", source)
+	}
+	for i := range orig {
+		if orig[i] != result[i] {
+			return fmt.Errorf("%s: formatting changed statement %s to %s", source, orig[i], result[i])
+		}
+	}
+	return nil
+}
+
+func statements(elems []scanner.Element) []string {
+	var result []string
+	for _, e := range elems {
+		if e.IsToken() {
+			token := e.Token()
+			if e.Closes() != "" {
+				token = "end" + e.Closes()
+			}
+			result = append(result, fmt.Sprintf("%s %t %q", token, e.IsFlagged(), e.Tags()))
+		}
+	}
+	return result
+}
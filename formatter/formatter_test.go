@@ -0,0 +1,87 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package formatter_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/mandelsoft/mdgen/formatter"
+	_ "github.com/mandelsoft/mdgen/statements"
+)
+
+func format(src string, style EndStyle) string {
+	data, err := Format("test", []byte(src), Options{End: style})
+	ExpectWithOffset(1, err).To(Succeed())
+	return string(data)
+}
+
+var _ = Describe("formatter", func() {
+	It("normalizes the spacing inside statements", func() {
+		Expect(format("{{ section  intro }}Title\n{{link   #intro}}here{{ end }}\n{{endsection}}\n", END_KEEP)).
+			To(Equal("{{section intro}}Title\n{{link #intro}}here{{end}}\n{{endsection}}\n"))
+	})
+
+	It("preserves quotes, escapes, comments and text", func() {
+		src := "/# comment\n{{section  \"a b\"}}Title  with \\{{escaped}}  text\n{{title   #a\\ b}}\n{{endsection}}\n"
+		Expect(format(src, END_KEEP)).To(Equal("/# comment\n{{section \"a b\"}}Title  with \\{{escaped}}  text\n{{title #a\\ b}}\n{{endsection}}\n"))
+	})
+
+	It("keeps closing braces separated from tags ending with a brace", func() {
+		Expect(format("{{block /x}}\n{{section /x/{scope}  }}T\n{{endsection}}\n{{endblock}}\n", END_KEEP)).
+			To(Equal("{{block /x}}\n{{section /x/{scope} }}T\n{{endsection}}\n{{endblock}}\n"))
+	})
+
+	It("converts end tokens", func() {
+		src := "{{section intro}}Title\n{{link #intro}}here{{end}}\n{{endsection}}\n"
+		Expect(format(src, END_SHORT)).To(Equal("{{section intro}}Title\n{{link #intro}}here{{end}}\n{{end}}\n"))
+		Expect(format(src, END_LONG)).To(Equal("{{section intro}}Title\n{{link #intro}}here{{endlink}}\n{{endsection}}\n"))
+	})
+
+	It("preserves the newline handling of converted end tokens", func() {
+		src := "{{block /x}}\n{{section /x/a}}T\n{{endsection}}\ntext{{endblock}}\nmore\n"
+		Expect(format(src, END_SHORT)).To(Equal("{{block /x}}\n{{section /x/a}}T\n{{end}}\ntext{{endblock}}\nmore\n"))
+
+		src = "{{block /x}}\ntext{{end}}\nmore\n"
+		Expect(format(src, END_LONG)).To(Equal("{{block /x}}\ntext{{endblock}}\\\nmore\n"))
+	})
+
+	It("indents arg and param statements", func() {
+		src := "{{block /x}}\n{{param a}}\n    {{*param b}}default{{endparam}}\n{{value a}}{{value b}}\n{{endblock}}\n" +
+			"{{blockref /x}}\n{{arg a}}\nvalue\n{{endarg}}\n\n\t{{arg b}}B{{endarg}}\n"
+		Expect(format(src, END_KEEP)).To(Equal("{{block /x}}\n  {{param a}}\n  {{*param b}}default{{endparam}}\n{{value a}}{{value b}}\n{{endblock}}\n" +
+			"{{blockref /x}}\n  {{arg a}}\nvalue\n{{endarg}}\n\n  {{arg b}}B{{endarg}}\n"))
+	})
+
+	It("aligns flagged sectionref lists", func() {
+		src := "{{section /main}}Main\n" +
+			"- {{*sectionref #/a}}{{title}}{{end}}\n" +
+			"- {{*sectionref   #/long}}{{title}}{{end}}\n" +
+			"\n" +
+			"- {{*sectionref #/other  }}{{title}}{{end}}\n" +
+			"{{endsection}}\n"
+		Expect(format(src, END_KEEP)).To(Equal("{{section /main}}Main\n" +
+			"- {{*sectionref #/a   }}{{title}}{{end}}\n" +
+			"- {{*sectionref #/long}}{{title}}{{end}}\n" +
+			"\n" +
+			"- {{*sectionref #/other}}{{title}}{{end}}\n" +
+			"{{endsection}}\n"))
+	})
+
+	It("is idempotent", func() {
+		src := "{{section intro}}Title\n{{blockref /x}}\n{{arg a}}A{{end}}\n{{endsection}}\n{{block /x}}{{param a}}{{value a}}{{endblock}}\n"
+		once := format(src, END_LONG)
+		Expect(format(once, END_LONG)).To(Equal(once))
+	})
+
+	It("rejects documents with syntax errors", func() {
+		_, err := Format("test", []byte("{{link #x}}text\n"), Options{})
+		Expect(err).To(HaveOccurred())
+		_, err = Format("test", []byte("text\n"), Options{End: "mixed"})
+		Expect(err).To(MatchError(`invalid end style "mixed"`))
+	})
+})
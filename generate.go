@@ -0,0 +1,164 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"strings"
+
+	"github.com/mandelsoft/mdgen/config"
+	"github.com/mandelsoft/mdgen/tree"
+)
+
+var generateCmd = &Command{
+	Name:  "generate",
+	Args:  "[<source> [<target>]]",
+	Short: "generate the markdown tree for a source tree",
+	Long: `
+Generate the markdown files for all mdg files found in the source folder
+(default: current folder) into the target folder (default: doc). Only changed
+target documents are rewritten.
+
+If the source folder contains a project configuration file mdgen.yaml, it is
+used to configure the source and target folder and further processing options.
+Command line arguments and options override the configuration.
+`,
+	Setup: func(fs *flag.FlagSet) func(args []string) error {
+		var flags CommonFlags
+		var prune, watch, print bool
+
+		flags.AddFlags(fs)
+		flags.AddIndexFlags(fs)
+		flags.AddFormatFlag(fs)
+		fs.BoolVar(&prune, "prune", false, "delete previously generated files, which are not generated anymore")
+		fs.BoolVar(&watch, "watch", false, "keep running and regenerate the target tree on source changes")
+		fs.BoolVar(&print, "doc", false, "print the parsed document tree")
+
+		options := func(args []string) (Options, string, string, error) {
+			opts, src, dst, err := flags.Options(args)
+			if err != nil {
+				return opts, src, dst, err
+			}
+			if flags.IsSet("prune") {
+				opts.Prune = prune
+			}
+			opts.Print = print
+			return opts, src, dst, nil
+		}
+
+		return func(args []string) error {
+			if len(args) > 2 {
+				return usageErrorf("at most source and target folder expected")
+			}
+			opts, src, dst, err := options(args)
+			if err != nil {
+				return err
+			}
+
+			if !watch {
+				_, err := Generate(src, dst, opts)
+				if err != nil {
+					return flags.Report(err)
+				}
+				return nil
+			}
+
+			w, err := tree.NewWatcher(src, []string{dst})
+			if err != nil {
+				return err
+			}
+			for {
+				var deps []string
+				t, err := Generate(src, dst, opts)
+				if err != nil {
+					flags.Report(err)
+				}
+				if t != nil {
+					deps = t.Dependencies()
+				}
+				if opts.Config != "" {
+					deps = append(deps, opts.Config)
+				}
+				err = w.SetDependencies(deps)
+				if err != nil {
+					return err
+				}
+				opts.Logger.Infof("watching %s for changes...", src)
+				changed, err := w.Wait(nil)
+				if err != nil {
+					return err
+				}
+				opts.Logger.Infof("detected changes: %s", strings.Join(changed, ", "))
+
+				// reevaluate a changed project configuration
+				o, _, _, err := options(args)
+				if err != nil {
+					if !errors.Is(err, errReported) {
+						flags.Report(err)
+					}
+					continue
+				}
+				opts = o
+			}
+		}
+	},
+}
+
+// TargetWriter is a tree writer generating a target folder.
+type TargetWriter interface {
+	tree.TreeWriter
+	SetPrune(bool)
+	Pruned() []string
+}
+
+// Generate generates the target tree for the given source folder.
+// Only changed target documents are rewritten. If pruning is enabled,
+// previously generated files not generated anymore are deleted.
+// The (possibly partially) resolved tree is returned to observe
+// its dependencies.
+func Generate(src, dst string, opts Options) (tree.Tree, error) {
+	t, err := Load(src, opts)
+	if err != nil {
+		return t, err
+	}
+
+	var tw TargetWriter
+	switch opts.Format {
+	case config.FORMAT_HTML:
+		w, err := tree.NewHTMLTreeWriter(dst)
+		if err != nil {
+			return t, err
+		}
+		w.SetNavigation(t.Navigation())
+		w.SetDocuments(t.Documents())
+		tw = w
+	case config.FORMAT_WIKI:
+		w, err := tree.NewWikiTreeWriter(dst)
+		if err != nil {
+			return t, err
+		}
+		w.SetNavigation(t.Navigation())
+		tw = w
+	default:
+		tw, err = tree.NewFileTreeWriter(dst)
+		if err != nil {
+			return t, err
+		}
+	}
+	tw.SetPrune(opts.Prune)
+	err = t.Emit(tw)
+	if err != nil {
+		// keep the old manifest, the generated tree is incomplete
+		return t, err
+	}
+	err = tw.Close()
+	for _, p := range tw.Pruned() {
+		opts.Logger.Infof("pruned %s", p)
+	}
+	return t, err
+}
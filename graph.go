@@ -0,0 +1,83 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+var graphCmd = &Command{
+	Name:  "graph",
+	Args:  "[<source>]",
+	Short: "print the document structure of a source tree",
+	Long: `
+Resolve the source tree and print the document structure. Every root document
+is shown together with the hierarchy of its sub documents, their source files,
+section numbers and titles.
+`,
+	Setup: func(fs *flag.FlagSet) func(args []string) error {
+		var flags CommonFlags
+
+		flags.AddFlags(fs)
+
+		return func(args []string) error {
+			if len(args) > 1 {
+				return usageErrorf("at most a source folder expected")
+			}
+			opts, src, _, err := flags.Options(args)
+			if err != nil {
+				return err
+			}
+			t, err := Load(src, opts)
+			if err != nil {
+				return flags.Report(err)
+			}
+			t.PrintStructure(os.Stdout)
+			return nil
+		}
+	},
+}
+
+var refsCmd = &Command{
+	Name:  "refs",
+	Args:  "[<source>]",
+	Short: "print the reference index of a source tree",
+	Long: `
+Resolve the source tree and print the reference index. It lists all links,
+which can be used to refer to documents, sections and other labeled elements,
+together with the target document and anchor they are resolved to.
+`,
+	Setup: func(fs *flag.FlagSet) func(args []string) error {
+		var flags CommonFlags
+
+		flags.AddFlags(fs)
+
+		return func(args []string) error {
+			if len(args) > 1 {
+				return usageErrorf("at most a source folder expected")
+			}
+			opts, src, _, err := flags.Options(args)
+			if err != nil {
+				return err
+			}
+			t, err := Load(src, opts)
+			if err != nil {
+				return flags.Report(err)
+			}
+			for _, r := range t.References() {
+				target := r.RefPath
+				if r.Anchor != "" {
+					target += "#" + r.Anchor
+				}
+				fmt.Printf("%s -> %s\n", r.Link, target)
+			}
+			return nil
+		}
+	},
+}
@@ -0,0 +1,55 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mandelsoft/mdgen/importer"
+	"github.com/mandelsoft/mdgen/logging"
+)
+
+var importCmd = &Command{
+	Name:  "import",
+	Args:  "<md-dir> <src-dir>",
+	Short: "convert a markdown tree into a source tree",
+	Long: `
+Convert the markdown files of a folder into source documents of a new source
+folder. Headings are converted into section statements with stable anchors
+derived from the document path and the heading title, for example
+/guide/setup/installation for the heading Installation of the file
+guide/setup.md. Inline links to files and headings of the markdown tree
+(file.md#heading-slug) are converted into link statements. Links which
+cannot be resolved are kept and reported as warnings.
+
+If all headings of a file carry explicit numbers (like 1.2 Setup), the
+numbers are removed and generated by the section numbering. Otherwise the
+numbering is disabled with {{numberrange section:V}}.
+
+All other files are copied. Existing files are never overwritten.
+`,
+	Setup: func(fs *flag.FlagSet) func(args []string) error {
+		quiet := fs.Bool("q", false, "quiet mode, do not report unresolved links")
+
+		return func(args []string) error {
+			if len(args) != 2 {
+				return usageErrorf("markdown folder and source folder expected")
+			}
+			log := logging.Default()
+			if *quiet {
+				log = logging.New(os.Stderr, logging.ErrorLevel, logging.FORMAT_TEXT)
+			}
+			files, err := importer.Import(args[0], args[1], log)
+			for _, f := range files {
+				fmt.Printf("created %s\n", f)
+			}
+			return err
+		}
+	},
+}
@@ -0,0 +1,382 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package importer
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/mandelsoft/mdgen/render"
+)
+
+var (
+	atxExp      = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextExp   = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	fenceExp    = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	numberExp   = regexp.MustCompile(`^[0-9]+(?:\.[0-9]+)*\.?[ \t]+(\S.*)$`)
+	anchorsExp  = regexp.MustCompile(`^[ \t]*(?:<a(?:\s[^>]*)?/?>[ \t]*(?:</a>)?[ \t]*)+$`)
+	anchorExp   = regexp.MustCompile(`<a\s[^>]*\b(?:id|name)="([^"]+)"`)
+	noParagraph = "#>-*+|=`~<"
+)
+
+// heading describes a markdown heading found in a document.
+type heading struct {
+	// line is the index of the first line of the heading,
+	// lines is the number of lines used by the heading and
+	// start is the index of the first line of the HTML anchors
+	// preceding the heading.
+	line  int
+	lines int
+	start int
+	level int
+	title string
+
+	// slug is the anchor generated by the markdown renderer,
+	// ids are the explicit HTML anchors preceding the heading
+	// and anchor is the tag used for the section statement.
+	slug   string
+	ids    []string
+	anchor string
+}
+
+// document is a markdown document of the imported tree.
+type document struct {
+	// path is the slash separated path of the document
+	// relative to the root of the imported tree.
+	path     string
+	lines    []string
+	code     []bool
+	headings []*heading
+	numbered bool
+}
+
+func newDocument(path string, data []byte) *document {
+	d := &document{
+		path:  path,
+		lines: strings.Split(string(data), "\n"),
+	}
+	d.code = make([]bool, len(d.lines))
+
+	fence := ""
+	for i, l := range d.lines {
+		if fence != "" {
+			d.code[i] = true
+			if m := fenceExp.FindStringSubmatch(l); m != nil && m[1][0] == fence[0] && len(m[1]) >= len(fence) && strings.TrimSpace(l[len(m[0]):]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if m := fenceExp.FindStringSubmatch(l); m != nil {
+			d.code[i] = true
+			fence = m[1]
+			continue
+		}
+		if m := atxExp.FindStringSubmatch(l); m != nil {
+			d.headings = append(d.headings, &heading{line: i, lines: 1, level: len(m[1]), title: m[2]})
+			continue
+		}
+		if i+1 < len(d.lines) && d.paragraph(i) {
+			if m := setextExp.FindStringSubmatch(d.lines[i+1]); m != nil {
+				level := 1
+				if m[1][0] == '-' {
+					level = 2
+				}
+				d.headings = append(d.headings, &heading{line: i, lines: 2, level: level, title: strings.TrimSpace(l)})
+				d.code[i+1] = true
+			}
+		}
+	}
+
+	d.numbered = len(d.headings) > 0
+	for _, h := range d.headings {
+		if !numberExp.MatchString(h.title) {
+			d.numbered = false
+		}
+	}
+
+	slugs := map[string]bool{}
+	anchors := map[string]bool{}
+	for _, h := range d.headings {
+		h.slug = unique(render.Slug(h.title), slugs)
+		if d.numbered {
+			h.title = numberExp.FindStringSubmatch(h.title)[1]
+		}
+		slug := render.Slug(h.title)
+		if slug == "" {
+			slug = "section"
+		}
+		h.anchor = "/" + strings.ReplaceAll(d.docpath(), " ", "-") + "/" + unique(slug, anchors)
+		h.start = h.line
+		for h.start > 0 && !d.code[h.start-1] && anchorsExp.MatchString(d.lines[h.start-1]) {
+			h.start--
+			for _, m := range anchorExp.FindAllStringSubmatch(d.lines[h.start], -1) {
+				h.ids = append(h.ids, m[1])
+			}
+		}
+	}
+	return d
+}
+
+// paragraph checks whether the given line is a single line paragraph,
+// which can be used as title of a setext heading.
+func (d *document) paragraph(i int) bool {
+	l := strings.TrimSpace(d.lines[i])
+	if l == "" || strings.ContainsRune(noParagraph, rune(l[0])) || strings.HasPrefix(d.lines[i], "    ") || strings.HasPrefix(d.lines[i], "\t") {
+		return false
+	}
+	return i == 0 || strings.TrimSpace(d.lines[i-1]) == ""
+}
+
+// docpath provides the path of the document without the suffix.
+func (d *document) docpath() string {
+	return strings.TrimSuffix(d.path, ".md")
+}
+
+// anchor provides the anchor for a fragment of a markdown link
+// to this document. The fragment may be the slug of a heading
+// or an explicit HTML anchor preceding a heading.
+func (d *document) anchor(fragment string) string {
+	if fragment == "" {
+		if len(d.headings) == 0 {
+			return ""
+		}
+		return d.headings[0].anchor
+	}
+	if f, err := url.PathUnescape(fragment); err == nil {
+		fragment = f
+	}
+	for _, h := range d.headings {
+		for _, id := range h.ids {
+			if id == fragment {
+				return h.anchor
+			}
+		}
+	}
+	fragment = strings.ToLower(fragment)
+	for _, h := range d.headings {
+		if h.slug == fragment {
+			return h.anchor
+		}
+	}
+	return ""
+}
+
+func unique(name string, used map[string]bool) string {
+	result := name
+	for i := 1; used[result]; i++ {
+		result = fmt.Sprintf("%s-%d", name, i)
+	}
+	used[result] = true
+	return result
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// converter converts a document into the source of an mdg document.
+type converter struct {
+	tree     *tree
+	doc      *document
+	warnings []string
+	out      strings.Builder
+}
+
+func (c *converter) convert() []byte {
+	d := c.doc
+	if !d.numbered && len(d.headings) > 0 {
+		c.out.WriteString("{{numberrange section:V}}\n")
+	}
+	var levels []int
+	next := 0
+	for i := 0; i < len(d.lines); i++ {
+		if next < len(d.headings) && d.headings[next].start == i {
+			h := d.headings[next]
+			for len(levels) > 0 && levels[len(levels)-1] >= h.level {
+				c.out.WriteString("{{endsection}}\n")
+				levels = levels[:len(levels)-1]
+			}
+		}
+		if next < len(d.headings) && d.headings[next].line == i {
+			h := d.headings[next]
+			levels = append(levels, h.level)
+			c.out.WriteString("{{section " + h.anchor + "}}" + escape(h.title, false))
+			i += h.lines - 1
+			next++
+		} else if d.code[i] {
+			c.out.WriteString(escape(d.lines[i], true))
+		} else {
+			c.inline(d.lines[i])
+		}
+		if i < len(d.lines)-1 {
+			c.out.WriteString("\n")
+		}
+	}
+	if len(levels) > 0 {
+		if !strings.HasSuffix(c.out.String(), "\n") {
+			c.out.WriteString("\n")
+		}
+		c.out.WriteString(strings.Repeat("{{endsection}}\n", len(levels)))
+	}
+	return []byte(c.out.String())
+}
+
+// inline converts a line of regular markdown text. Inline links
+// to resolvable headings are replaced by link statements.
+func (c *converter) inline(line string) {
+	start := 0
+	text := func(end int) {
+		c.out.WriteString(escape(line[start:end], start == 0 && end == len(line)))
+	}
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '`':
+			n := run(line, i, '`')
+			if end := strings.Index(line[i+n:], line[i:i+n]); end >= 0 {
+				i += n + end + n - 1
+			} else {
+				i += n - 1
+			}
+		case '!':
+			if i+1 < len(line) && line[i+1] == '[' {
+				i++
+			}
+		case '[':
+			content, dest, end := link(line, i)
+			if end < 0 {
+				continue
+			}
+			anchor := c.resolve(dest)
+			if anchor == "" {
+				i = end - 1
+				continue
+			}
+			text(i)
+			c.out.WriteString("{{link #" + anchor + "}}" + escape(content, false) + "{{endlink}}")
+			start = end
+			i = end - 1
+		}
+	}
+	if start < len(line) || start == 0 {
+		text(len(line))
+	}
+}
+
+// resolve provides the anchor for the destination of a markdown link.
+func (c *converter) resolve(dest string) string {
+	if strings.HasPrefix(dest, "<") && strings.HasSuffix(dest, ">") {
+		dest = dest[1 : len(dest)-1]
+	}
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" || u.Host != "" || strings.HasPrefix(u.Path, "/") {
+		return ""
+	}
+	target := c.doc
+	if u.Path != "" {
+		if !strings.HasSuffix(u.Path, ".md") {
+			return ""
+		}
+		target = c.tree.docs[path.Join(path.Dir(c.doc.path), u.Path)]
+		if target == nil {
+			c.warnings = append(c.warnings, fmt.Sprintf("%s: link target %q not found", c.doc.path, dest))
+			return ""
+		}
+	}
+	anchor := target.anchor(u.Fragment)
+	if anchor == "" && u.Fragment != "" {
+		c.warnings = append(c.warnings, fmt.Sprintf("%s: heading for link %q not found", c.doc.path, dest))
+	}
+	return anchor
+}
+
+// link parses an inline link starting with the opening bracket at the
+// given index. It provides the link text, its destination and the index
+// after the link. If there is no inline link or the link has a title,
+// the index is negative.
+func link(line string, i int) (string, string, int) {
+	level := 0
+	j := i
+	for ; j < len(line); j++ {
+		switch line[j] {
+		case '\\':
+			j++
+			continue
+		case '[':
+			level++
+		case ']':
+			level--
+		}
+		if level == 0 {
+			break
+		}
+	}
+	if j >= len(line)-1 || line[j+1] != '(' {
+		return "", "", -1
+	}
+	end := strings.IndexByte(line[j+2:], ')')
+	if end < 0 {
+		return "", "", -1
+	}
+	dest := strings.TrimSpace(line[j+2 : j+2+end])
+	if dest == "" || strings.ContainsAny(dest, " \t") {
+		return "", "", -1
+	}
+	return line[i+1 : j], dest, j + 2 + end + 1
+}
+
+func run(line string, i int, c byte) int {
+	n := 0
+	for i+n < len(line) && line[i+n] == c {
+		n++
+	}
+	return n
+}
+
+// escape escapes the character sequences of a text, which would
+// otherwise be interpreted by the mdg scanner: backslashes preceding
+// braces, other backslashes or the end of the line, the statement
+// start sequence and the comment sequence. If line is set, the text
+// describes a complete line.
+func escape(text string, line bool) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\\':
+			n := run(text, i, '\\')
+			if i+n == len(text) || n > 1 || strings.IndexByte("{}", text[i+n]) >= 0 {
+				b.WriteString(strings.Repeat("\\", 2*n))
+			} else {
+				b.WriteString(strings.Repeat("\\", n))
+			}
+			i += n - 1
+		case text[i] == '{':
+			n := run(text, i, '{')
+			if n > 1 {
+				b.WriteString(strings.Repeat("\\{", n-1))
+			}
+			if i+n == len(text) && !line {
+				// the text may be followed by a statement
+				b.WriteString("\\")
+			}
+			b.WriteString("{")
+			i += n - 1
+		case strings.HasPrefix(text[i:], "/#"):
+			b.WriteString("{{cs}}")
+			if line && i == 0 && len(text) == 2 {
+				// keep the newline following the statement
+				b.WriteString("\\")
+			}
+			i++
+		default:
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
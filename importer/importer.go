@@ -0,0 +1,162 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package importer
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/mdgen/logging"
+	"github.com/mandelsoft/mdgen/utils"
+)
+
+// Tree is a tree of markdown documents converted into mdg sources.
+// Headings are converted into section statements using anchors
+// derived from the document path and the heading title. Inline links
+// to headings of the tree are converted into link statements.
+type Tree = *tree
+
+type tree struct {
+	docs map[string]*document
+}
+
+// NewTree creates an empty markdown tree.
+func NewTree() Tree {
+	return &tree{docs: map[string]*document{}}
+}
+
+// Add adds a markdown document with the given slash separated
+// path relative to the root of the tree.
+func (t *tree) Add(path string, data []byte) {
+	t.docs[path] = newDocument(path, data)
+}
+
+// Documents provides the sorted paths of the documents of the tree.
+func (t *tree) Documents() []string {
+	return utils.StringMapKeys(t.docs)
+}
+
+// Convert provides the mdg source for the document with the given path
+// and warnings for links, which cannot be resolved.
+func (t *tree) Convert(path string) ([]byte, []string, error) {
+	d := t.docs[path]
+	if d == nil {
+		return nil, nil, fmt.Errorf("unknown document %q", path)
+	}
+	c := &converter{tree: t, doc: d}
+	return c.convert(), c.warnings, nil
+}
+
+// Source provides the path of the mdg document
+// generated for a markdown document.
+func Source(path string) string {
+	return strings.TrimSuffix(path, ".md") + ".mdg"
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Import converts the markdown documents found in the folder src
+// into mdg documents in the folder dst. All other files are copied.
+// Hidden files and folders are ignored. Existing files are never
+// overwritten. It provides the created files.
+func Import(src, dst string, log logging.Logger, fss ...vfs.FileSystem) ([]string, error) {
+	fs := utils.OptionalDefaulted(osfs.New(), fss...)
+	log = utils.OptionalDefaulted(logging.Discard(), log)
+
+	files, err := scan(fs, src, "", path.Clean(dst))
+	if err != nil {
+		return nil, err
+	}
+	t := NewTree()
+	for _, f := range files {
+		if strings.HasSuffix(f, ".md") {
+			data, err := vfs.ReadFile(fs, path.Join(src, f))
+			if err != nil {
+				return nil, err
+			}
+			t.Add(f, data)
+		}
+	}
+	for _, f := range files {
+		p := path.Join(dst, target(f))
+		ok, err := vfs.Exists(fs, p)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return nil, fmt.Errorf("%s already exists", p)
+		}
+	}
+
+	var created []string
+	for _, f := range files {
+		var data []byte
+		if strings.HasSuffix(f, ".md") {
+			var warnings []string
+			data, warnings, err = t.Convert(f)
+			for _, w := range warnings {
+				log.Warnf("%s", w)
+			}
+		} else {
+			data, err = vfs.ReadFile(fs, path.Join(src, f))
+		}
+		if err != nil {
+			return created, err
+		}
+		p := path.Join(dst, target(f))
+		err = fs.MkdirAll(path.Dir(p), 0o755)
+		if err == nil {
+			err = vfs.WriteFile(fs, p, data, 0o644)
+		}
+		if err != nil {
+			return created, err
+		}
+		created = append(created, p)
+	}
+	return created, nil
+}
+
+func target(f string) string {
+	if strings.HasSuffix(f, ".md") {
+		return Source(f)
+	}
+	return f
+}
+
+// scan provides the files found in a folder relative to the
+// root of the imported tree, omitting the destination folder.
+func scan(fs vfs.FileSystem, root, rel string, dst string) ([]string, error) {
+	p := path.Join(root, rel)
+	list, err := vfs.ReadDir(fs, p)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, f := range list {
+		if strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		name := path.Join(rel, f.Name())
+		if f.IsDir() {
+			if path.Join(root, name) == dst {
+				continue
+			}
+			files, err := scan(fs, root, name, dst)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, files...)
+		} else {
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
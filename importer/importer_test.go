@@ -0,0 +1,128 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package importer_test
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "github.com/mandelsoft/mdgen/importer"
+	"github.com/mandelsoft/mdgen/scanner"
+	_ "github.com/mandelsoft/mdgen/statements"
+)
+
+func convert(t Tree, path string) (string, []string) {
+	data, warnings, err := t.Convert(path)
+	ExpectWithOffset(1, err).To(Succeed())
+	return string(data), warnings
+}
+
+var _ = Describe("importer", func() {
+	It("converts headings of unnumbered documents", func() {
+		t := NewTree()
+		t.Add("guide/setup.md", []byte("# Setup\nintro\n\n## Install\ntext\n\nInstall\n-------\n\n# Usage\n"))
+		src, warnings := convert(t, "guide/setup.md")
+		Expect(warnings).To(BeEmpty())
+		Expect(src).To(Equal(`{{numberrange section:V}}
+{{section /guide/setup/setup}}Setup
+intro
+
+{{section /guide/setup/install}}Install
+text
+
+{{endsection}}
+{{section /guide/setup/install-1}}Install
+
+{{endsection}}
+{{endsection}}
+{{section /guide/setup/usage}}Usage
+{{endsection}}
+`))
+	})
+
+	It("removes explicit numbers", func() {
+		t := NewTree()
+		t.Add("a.md", []byte("# 1. Intro\n## 1.1 Details\n"))
+		src, _ := convert(t, "a.md")
+		Expect(src).To(Equal("{{section /a/intro}}Intro\n{{section /a/details}}Details\n{{endsection}}\n{{endsection}}\n"))
+	})
+
+	It("ignores headings in code blocks", func() {
+		t := NewTree()
+		t.Add("a.md", []byte("# A\n```\n# comment\n```\n"))
+		src, _ := convert(t, "a.md")
+		Expect(src).To(Equal("{{numberrange section:V}}\n{{section /a/a}}A\n```\n# comment\n```\n{{endsection}}\n"))
+	})
+
+	It("converts resolvable links", func() {
+		t := NewTree()
+		t.Add("a.md", []byte("# A\nsee [B](sub/b.md#1-more-details), [b](sub/b.md), [here](#a),\n[web](https://x.org/b.md) and `[c](sub/b.md)`\n"))
+		t.Add("sub/b.md", []byte("# 1 More Details\n"))
+		src, warnings := convert(t, "a.md")
+		Expect(warnings).To(BeEmpty())
+		Expect(src).To(Equal(`{{numberrange section:V}}
+{{section /a/a}}A
+see {{link #/sub/b/more-details}}B{{endlink}}, {{link #/sub/b/more-details}}b{{endlink}}, {{link #/a/a}}here{{endlink}},
+[web](https://x.org/b.md) and ` + "`[c](sub/b.md)`" + `
+{{endsection}}
+`))
+	})
+
+	It("resolves explicit anchors and reports unresolved links", func() {
+		t := NewTree()
+		t.Add("a.md", []byte("<a id=\"intro\"></a>\n# A\n[x](#intro) [y](#other) [z](c.md)\n"))
+		src, warnings := convert(t, "a.md")
+		Expect(src).To(ContainSubstring("{{link #/a/a}}x{{endlink}} [y](#other) [z](c.md)"))
+		Expect(warnings).To(Equal([]string{
+			`a.md: heading for link "#other" not found`,
+			`a.md: link target "c.md" not found`,
+		}))
+	})
+
+	It("escapes scanner sequences", func() {
+		text := "a {{b}} \\{{c}} {{{d\\\n/# x \\x \\\\y\n/#\n"
+		t := NewTree()
+		t.Add("a.md", []byte(text))
+		src, _ := convert(t, "a.md")
+
+		elems, err := scanner.ParseRaw("a.mdg", strings.NewReader(src))
+		Expect(err).To(Succeed())
+		var result bytes.Buffer
+		for _, e := range elems {
+			if e.IsText() {
+				result.WriteString(e.Text())
+			} else {
+				Expect(e.Token()).To(Equal("cs"))
+				result.WriteString("/#")
+			}
+		}
+		Expect(result.String()).To(Equal(text))
+	})
+
+	It("imports a folder", func() {
+		fs := memoryfs.New()
+		Expect(fs.MkdirAll("md/sub", 0o755)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "md/README.md", []byte("# Readme\n"), 0o644)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "md/sub/image.png", []byte("png"), 0o644)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "md/.hidden", []byte("x"), 0o644)).To(Succeed())
+
+		files, err := Import("md", "md/src", nil, fs)
+		Expect(err).To(Succeed())
+		Expect(files).To(Equal([]string{"md/src/README.mdg", "md/src/sub/image.png"}))
+		data, err := vfs.ReadFile(fs, "md/src/README.mdg")
+		Expect(err).To(Succeed())
+		Expect(string(data)).To(Equal("{{numberrange section:V}}\n{{section /README/readme}}Readme\n{{endsection}}\n"))
+
+		_, err = Import("md", "md/src", nil, fs)
+		Expect(err).To(MatchError("md/src/README.mdg already exists"))
+	})
+})
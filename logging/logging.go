@@ -0,0 +1,167 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	ErrorLevel Level = iota
+	WarnLevel
+	InfoLevel
+	DebugLevel
+)
+
+// DefaultLevel is the log level used by the default logger.
+const DefaultLevel = WarnLevel
+
+var levels = map[Level]string{
+	ErrorLevel: "error",
+	WarnLevel:  "warn",
+	InfoLevel:  "info",
+	DebugLevel: "debug",
+}
+
+func (l Level) String() string {
+	if s, ok := levels[l]; ok {
+		return s
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// ParseLevel provides a level for its name.
+func ParseLevel(name string) (Level, error) {
+	for l, n := range levels {
+		if n == strings.ToLower(name) {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q", name)
+}
+
+type Format string
+
+const (
+	FORMAT_TEXT Format = "text"
+	FORMAT_JSON Format = "json"
+)
+
+// ParseFormat provides a log format for its name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FORMAT_TEXT, FORMAT_JSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown log format %q", name)
+}
+
+// Logger is used to report progress and problems during the
+// processing of a document tree. Messages are formatted like fmt.Printf
+// and only emitted if the level of the logger is enabled.
+type Logger interface {
+	Enabled(level Level) bool
+	Log(level Level, msg string, args ...interface{})
+
+	Errorf(msg string, args ...interface{})
+	Warnf(msg string, args ...interface{})
+	Infof(msg string, args ...interface{})
+	Debugf(msg string, args ...interface{})
+}
+
+type logger struct {
+	lock   sync.Mutex
+	writer io.Writer
+	level  Level
+	format Format
+}
+
+// New creates a logger writing messages up to the given
+// level to the given writer using the given format.
+func New(w io.Writer, level Level, format Format) Logger {
+	if w == nil {
+		w = io.Discard
+	}
+	return &logger{writer: w, level: level, format: format}
+}
+
+// Discard provides a logger suppressing all messages.
+func Discard() Logger {
+	return New(io.Discard, -1, FORMAT_TEXT)
+}
+
+var deflog = New(os.Stderr, DefaultLevel, FORMAT_TEXT)
+
+// Default provides the default logger, writing warnings and
+// errors to stderr.
+func Default() Logger {
+	return deflog
+}
+
+func (l *logger) Enabled(level Level) bool {
+	return level <= l.level
+}
+
+func (l *logger) Log(level Level, msg string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+
+	var line string
+	switch l.format {
+	case FORMAT_JSON:
+		data, err := json.Marshal(map[string]string{
+			"time":  time.Now().Format(time.RFC3339),
+			"level": level.String(),
+			"msg":   msg,
+		})
+		if err != nil {
+			return
+		}
+		line = string(data) + "\n"
+	default:
+		switch level {
+		case ErrorLevel:
+			line = "ERROR: " + msg + "\n"
+		case WarnLevel:
+			line = "WARN: " + msg + "\n"
+		default:
+			line = msg + "\n"
+		}
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	io.WriteString(l.writer, line)
+}
+
+func (l *logger) Errorf(msg string, args ...interface{}) {
+	l.Log(ErrorLevel, msg, args...)
+}
+
+func (l *logger) Warnf(msg string, args ...interface{}) {
+	l.Log(WarnLevel, msg, args...)
+}
+
+func (l *logger) Infof(msg string, args ...interface{}) {
+	l.Log(InfoLevel, msg, args...)
+}
+
+func (l *logger) Debugf(msg string, args ...interface{}) {
+	l.Log(DebugLevel, msg, args...)
+}
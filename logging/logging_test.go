@@ -0,0 +1,57 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/mdgen/logging"
+)
+
+var _ = Describe("logging", func() {
+	var buf *bytes.Buffer
+
+	BeforeEach(func() {
+		buf = &bytes.Buffer{}
+	})
+
+	It("filters levels", func() {
+		log := logging.New(buf, logging.InfoLevel, logging.FORMAT_TEXT)
+		log.Debugf("debug %d", 1)
+		log.Infof("info %d", 2)
+		log.Warnf("warn %d", 3)
+		log.Errorf("error %d", 4)
+		Expect(buf.String()).To(Equal("info 2\nWARN: warn 3\nERROR: error 4\n"))
+	})
+
+	It("discards", func() {
+		log := logging.Discard()
+		Expect(log.Enabled(logging.ErrorLevel)).To(BeFalse())
+		log.Errorf("error")
+	})
+
+	It("writes json", func() {
+		log := logging.New(buf, logging.DebugLevel, logging.FORMAT_JSON)
+		log.Debugf("debug %q", "msg")
+
+		var entry map[string]string
+		Expect(json.Unmarshal(buf.Bytes(), &entry)).To(Succeed())
+		Expect(entry["level"]).To(Equal("debug"))
+		Expect(entry["msg"]).To(Equal(`debug "msg"`))
+		Expect(entry["time"]).NotTo(BeEmpty())
+	})
+
+	It("parses levels", func() {
+		Expect(logging.ParseLevel("Debug")).To(Equal(logging.DebugLevel))
+		_, err := logging.ParseLevel("trace")
+		Expect(err).To(HaveOccurred())
+	})
+})
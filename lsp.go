@@ -0,0 +1,75 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/mdgen/lsp"
+	"github.com/mandelsoft/mdgen/tree"
+)
+
+var lspCmd = &Command{
+	Name:  "lsp",
+	Args:  "[<source>]",
+	Short: "run a language server for a source tree",
+	Long: `
+Run a language server implementing the Language Server Protocol on stdin and
+stdout. The source tree is reprocessed whenever a document is opened, changed,
+saved or closed in the editor, using the editor content for open documents.
+The documents are generated only initially and when a document is saved, this
+executes the commands of execute statements and reads the go packages of godoc
+statements. Imported reference inventories are read only once.
+
+It provides the diagnostics of the parser and the resolution, completion of
+statement keywords, anchors, term tags, text module and value names,
+go-to-definition for the tags of link, ref, term and blockref statements and
+hover information showing the glossary text of a term or the label and title
+of a referenced section.
+
+Log output is written to stderr.
+`,
+	Setup: func(fs *flag.FlagSet) func(args []string) error {
+		var flags CommonFlags
+
+		flags.AddFlags(fs)
+
+		return func(args []string) error {
+			if len(args) > 1 {
+				return usageErrorf("at most a source folder expected")
+			}
+			opts, src, dst, err := flags.Options(args)
+			if err != nil {
+				return err
+			}
+			// documents are identified by absolute file URIs
+			src, err = filepath.Abs(src)
+			if err != nil {
+				return err
+			}
+			opts.Copy = false
+			opts.Inventory = false
+			opts.Search = false
+			opts.InventoryCache = map[string]*tree.Inventory{}
+
+			load := func(fs vfs.FileSystem, generate bool) (tree.Tree, error) {
+				opts.FS = fs
+				t, err := Load(src, opts)
+				if err != nil || !generate {
+					return t, err
+				}
+				// some references are only checked when generating the documents
+				return t, t.Emit(tree.NewMemoryTreeWriter(dst))
+			}
+			return lsp.NewServer(load, opts.Logger).Run(os.Stdin, os.Stdout)
+		}
+	},
+}
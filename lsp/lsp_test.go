@@ -0,0 +1,180 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package lsp_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	. "github.com/mandelsoft/mdgen/lsp"
+	"github.com/mandelsoft/mdgen/tree"
+)
+
+const doc = `{{section intro}}Introduction
+The {{termdef tool}}tool{{description}}The generator.{{endtermdef}} uses
+a {{term tool}} to link {{link #intro}}{{end}}.
+{{endsection}}
+{{section glossary}}Glossary
+{{glossary}}
+{{endsection}}
+`
+
+func frame(msgs ...string) io.Reader {
+	buf := &bytes.Buffer{}
+	for _, m := range msgs {
+		fmt.Fprintf(buf, "Content-Length: %d\r\n\r\n%s", len(m), m)
+	}
+	return buf
+}
+
+func messages(out *bytes.Buffer) []map[string]interface{} {
+	var result []map[string]interface{}
+	r := bufio.NewReader(out)
+	for {
+		header, err := textproto.NewReader(r).ReadMIMEHeader()
+		if err != nil {
+			return result
+		}
+		n, err := strconv.Atoi(header.Get("Content-Length"))
+		Expect(err).To(Succeed())
+		data := make([]byte, n)
+		_, err = io.ReadFull(r, data)
+		Expect(err).To(Succeed())
+		var m map[string]interface{}
+		Expect(json.Unmarshal(data, &m)).To(Succeed())
+		result = append(result, m)
+	}
+}
+
+var _ = Describe("language server", func() {
+	Context("statements", func() {
+		It("detects keywords", func() {
+			info := StatementAt("see {{ter", 9)
+			Expect(info).To(Equal(&StatementInfo{Keyword: "ter", Start: 6, End: 9}))
+			info = StatementAt("see {{", 6)
+			Expect(info).To(Equal(&StatementInfo{Keyword: "", Start: 6, End: 6}))
+		})
+
+		It("detects tags", func() {
+			info := StatementAt("a {{term *tool}} b", 12)
+			Expect(info).To(Equal(&StatementInfo{Keyword: "term", Tag: "tool", OnTag: true, Start: 10, End: 14}))
+			info = StatementAt("{{blockref section:/statement}}", 22)
+			Expect(info).To(Equal(&StatementInfo{Keyword: "blockref", Tag: "/statement", OnTag: true, Start: 19, End: 29}))
+		})
+
+		It("ignores text outside of statements", func() {
+			Expect(StatementAt("a {{term tool}} b", 17)).To(BeNil())
+			Expect(StatementAt("a \\{{term tool}}", 10)).To(BeNil())
+			Expect(StatementAt("a {{term tool other}}", 17)).To(BeNil())
+		})
+	})
+
+	Context("server", func() {
+		var fs vfs.FileSystem
+
+		load := func(fs vfs.FileSystem, generate bool) (tree.Tree, error) {
+			t, err := tree.ForFolder("/src", fs)
+			if err != nil {
+				return nil, err
+			}
+			return t, t.Resolve()
+		}
+
+		BeforeEach(func() {
+			fs = memoryfs.New()
+			Expect(fs.MkdirAll("/src", 0o755)).To(Succeed())
+			Expect(vfs.WriteFile(fs, "/src/doc.mdg", []byte(doc), 0o644)).To(Succeed())
+		})
+
+		It("provides hover, definition and diagnostics", func() {
+			out := &bytes.Buffer{}
+			in := frame(
+				`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+				`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+				`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///src/doc.mdg"},"position":{"line":2,"character":12}}}`,
+				`{"jsonrpc":"2.0","id":3,"method":"textDocument/definition","params":{"textDocument":{"uri":"file:///src/doc.mdg"},"position":{"line":2,"character":33}}}`,
+				`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///src/doc.mdg","text":"{{term other}}\n"}}}`,
+				`{"jsonrpc":"2.0","id":4,"method":"shutdown"}`,
+				`{"jsonrpc":"2.0","method":"exit"}`,
+			)
+			Expect(NewServer(load, nil, fs).Run(in, out)).To(Succeed())
+
+			msgs := messages(out)
+			Expect(msgs).To(HaveLen(5))
+			Expect(msgs[0]["result"]).To(HaveKey("capabilities"))
+			Expect(msgs[1]["result"]).To(Equal(map[string]interface{}{
+				"contents": map[string]interface{}{"kind": "markdown", "value": "**tool** (plural: tools)\n\nThe generator."},
+				"range": map[string]interface{}{
+					"start": map[string]interface{}{"line": 2.0, "character": 9.0},
+					"end":   map[string]interface{}{"line": 2.0, "character": 13.0},
+				},
+			}))
+			Expect(msgs[2]["result"]).To(HaveKeyWithValue("uri", "file:///src/doc.mdg"))
+			Expect(msgs[2]["result"]).To(HaveKeyWithValue("range", HaveKeyWithValue("start", map[string]interface{}{"line": 0.0, "character": 0.0})))
+			Expect(msgs[3]["method"]).To(Equal("textDocument/publishDiagnostics"))
+			Expect(msgs[3]["params"]).To(HaveKeyWithValue("diagnostics", HaveLen(1)))
+			Expect(msgs[4]).To(HaveKeyWithValue("result", BeNil()))
+		})
+
+		It("completes values in scope and generates only saved documents", func() {
+			var generated []bool
+			load := func(fs vfs.FileSystem, generate bool) (tree.Tree, error) {
+				generated = append(generated, generate)
+				t, err := tree.ForFolder("/src", fs)
+				if err != nil {
+					return nil, err
+				}
+				t.SetValues(map[string]string{"product": "mdgen"})
+				return t, t.Resolve()
+			}
+			Expect(vfs.WriteFile(fs, "/src/block.mdg", []byte(`{{block /outer}}{{param name}}
+{{block inner}}{{param text}}{{param title}}
+Note: {{value text}}
+{{endblock}}
+{{endblock}}
+{{value product}}
+`), 0o644)).To(Succeed())
+
+			out := &bytes.Buffer{}
+			in := frame(
+				`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+				`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+				`{"jsonrpc":"2.0","id":2,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///src/block.mdg"},"position":{"line":2,"character":16}}}`,
+				`{"jsonrpc":"2.0","id":3,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///src/block.mdg"},"position":{"line":5,"character":10}}}`,
+				`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///src/block.mdg"},"contentChanges":[{"text":"{{value product}}\n"}]}}`,
+				`{"jsonrpc":"2.0","method":"textDocument/didSave","params":{"textDocument":{"uri":"file:///src/block.mdg"}}}`,
+				`{"jsonrpc":"2.0","id":4,"method":"shutdown"}`,
+				`{"jsonrpc":"2.0","method":"exit"}`,
+			)
+			Expect(NewServer(load, nil, fs).Run(in, out)).To(Succeed())
+			Expect(generated).To(Equal([]bool{true, false, true}))
+
+			labels := func(m map[string]interface{}) []string {
+				var result []string
+				for _, e := range m["result"].([]interface{}) {
+					result = append(result, e.(map[string]interface{})["label"].(string))
+				}
+				return result
+			}
+			msgs := messages(out)
+			Expect(msgs).To(HaveLen(4))
+			Expect(labels(msgs[1])).To(Equal([]string{"name", "product", "text", "title"}))
+			Expect(labels(msgs[2])).To(Equal([]string{"product"}))
+		})
+	})
+})
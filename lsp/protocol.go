@@ -0,0 +1,274 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The subset of the Language Server Protocol used by the server.
+// See https://microsoft.github.io/language-server-protocol/.
+
+// JSON-RPC error codes.
+const (
+	CODE_PARSE_ERROR      = -32700
+	CODE_INVALID_REQUEST  = -32600
+	CODE_METHOD_NOT_FOUND = -32601
+	CODE_INVALID_PARAMS   = -32602
+	CODE_INTERNAL_ERROR   = -32603
+)
+
+// Diagnostic severities.
+const (
+	SEVERITY_ERROR   = 1
+	SEVERITY_WARNING = 2
+)
+
+// Completion item kinds.
+const (
+	COMPLETION_KEYWORD   = 14
+	COMPLETION_REFERENCE = 18
+)
+
+// TEXT_SYNC_FULL requests the complete content of a document
+// for every change.
+const TEXT_SYNC_FULL = 1
+
+// Message is a JSON-RPC message sent by the client. It is a request,
+// if it provides an id, otherwise a notification.
+type Message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+// Response is the JSON-RPC response for a request. A successful
+// response always provides a result, which might be null.
+type Response struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *ResponseError   `json:"error,omitempty"`
+}
+
+// notification is a JSON-RPC message sent by the server
+// without expecting a response.
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string {
+	return e.Message
+}
+
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+type DidChangeTextDocumentParams struct {
+	TextDocument   TextDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type DidSaveTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Code     string `json:"code,omitempty"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+type CompletionItem struct {
+	Label    string    `json:"label"`
+	Kind     int       `json:"kind,omitempty"`
+	TextEdit *TextEdit `json:"textEdit,omitempty"`
+}
+
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type Hover struct {
+	Contents MarkupContent `json:"contents"`
+	Range    *Range        `json:"range,omitempty"`
+}
+
+// Message types for window/logMessage.
+const (
+	MESSAGE_ERROR   = 1
+	MESSAGE_WARNING = 2
+	MESSAGE_INFO    = 3
+)
+
+type LogMessageParams struct {
+	Type    int    `json:"type"`
+	Message string `json:"message"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// connection reads and writes JSON-RPC messages using the
+// base protocol of LSP (messages prefixed by a Content-Length header).
+type connection struct {
+	in   *bufio.Reader
+	lock sync.Mutex
+	out  io.Writer
+}
+
+func newConnection(in io.Reader, out io.Writer) *connection {
+	return &connection{in: bufio.NewReader(in), out: out}
+}
+
+func (c *connection) Read() (*Message, error) {
+	header, err := textproto.NewReader(c.in).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header %q", header.Get("Content-Length"))
+	}
+	data := make([]byte, length)
+	_, err = io.ReadFull(c.in, data)
+	if err != nil {
+		return nil, err
+	}
+	var msg Message
+	err = json.Unmarshal(data, &msg)
+	if err != nil {
+		return nil, &ResponseError{CODE_PARSE_ERROR, err.Error()}
+	}
+	return &msg, nil
+}
+
+func (c *connection) write(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, err = fmt.Fprintf(c.out, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	return err
+}
+
+// Reply sends the response for a request. If err is not nil,
+// an error response is sent.
+func (c *connection) Reply(id *json.RawMessage, result interface{}, err error) error {
+	resp := &Response{JSONRPC: "2.0", ID: id}
+	if err != nil {
+		rerr, ok := err.(*ResponseError)
+		if !ok {
+			rerr = &ResponseError{Code: CODE_INTERNAL_ERROR, Message: err.Error()}
+		}
+		resp.Error = rerr
+	} else {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		resp.Result = data
+	}
+	return c.write(resp)
+}
+
+// Notify sends a notification to the client.
+func (c *connection) Notify(method string, params interface{}) error {
+	return c.write(&notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// FilePath provides the file path for a file URI.
+func FilePath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported document URI %q", uri)
+	}
+	return u.Path, nil
+}
+
+// FileURI provides the file URI for an absolute file path.
+func FileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// lines splits a text into its lines.
+func lines(text string) []string {
+	return strings.Split(text, "\n")
+}
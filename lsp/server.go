@@ -0,0 +1,403 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/mandelsoft/vfs/pkg/layerfs"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/mdgen/diagnostics"
+	"github.com/mandelsoft/mdgen/logging"
+	"github.com/mandelsoft/mdgen/scanner"
+	"github.com/mandelsoft/mdgen/tree"
+	"github.com/mandelsoft/mdgen/utils"
+)
+
+// Loader loads and resolves the source tree using the given filesystem.
+// If the tree cannot be resolved, the partially resolved tree is
+// returned together with the error, if possible.
+// If generate is set, the documents are generated, also, to check
+// the references only checked during the generation. This executes
+// commands and reads go packages, therefore it is only requested
+// for saved documents.
+type Loader func(fs vfs.FileSystem, generate bool) (tree.Tree, error)
+
+// Server is a language server for mdg source trees. It reprocesses
+// the source tree whenever a document is opened, changed, saved or
+// closed. The content of the documents opened by the client replaces
+// the content found in the filesystem. The documents are generated
+// only initially and when a document is saved.
+type Server = *server
+
+type server struct {
+	load Loader
+	fs   vfs.FileSystem
+	log  logging.Logger
+	conn *connection
+
+	open      map[string]string
+	tree      tree.Tree
+	published utils.Set[string]
+	shutdown  bool
+}
+
+// NewServer creates a language server for the source tree
+// provided by the given loader.
+func NewServer(load Loader, log logging.Logger, fss ...vfs.FileSystem) Server {
+	return &server{
+		load:      load,
+		fs:        utils.OptionalDefaulted(osfs.New(), fss...),
+		log:       utils.OptionalDefaulted(logging.Discard(), log),
+		open:      map[string]string{},
+		published: utils.Set[string]{},
+	}
+}
+
+// Run serves the language server protocol on the given streams
+// until the client sends the exit notification or closes the
+// input stream.
+func (s *server) Run(in io.Reader, out io.Writer) error {
+	s.conn = newConnection(in, out)
+	for {
+		msg, err := s.conn.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			var rerr *ResponseError
+			if errors.As(err, &rerr) {
+				s.log.Errorf("%s", err)
+				continue
+			}
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit without shutdown")
+			}
+			return nil
+		}
+		result, err := s.handle(msg)
+		if msg.ID != nil {
+			err = s.conn.Reply(msg.ID, result, err)
+		} else if err != nil {
+			s.log.Errorf("%s: %s", msg.Method, err)
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) handle(msg *Message) (interface{}, error) {
+	s.log.Debugf("received %s", msg.Method)
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": TEXT_SYNC_FULL,
+				"completionProvider": map[string]interface{}{
+					"triggerCharacters": []string{"{", " "},
+				},
+				"definitionProvider": true,
+				"hoverProvider":      true,
+			},
+			"serverInfo": map[string]interface{}{
+				"name": "mdgen",
+			},
+		}, nil
+	case "initialized":
+		return nil, s.reload(true)
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var p DidOpenTextDocumentParams
+		if err := decode(msg, &p); err != nil {
+			return nil, err
+		}
+		return nil, s.update(p.TextDocument.URI, false, func(file string) {
+			s.open[file] = p.TextDocument.Text
+		})
+	case "textDocument/didChange":
+		var p DidChangeTextDocumentParams
+		if err := decode(msg, &p); err != nil {
+			return nil, err
+		}
+		return nil, s.update(p.TextDocument.URI, false, func(file string) {
+			if n := len(p.ContentChanges); n > 0 {
+				s.open[file] = p.ContentChanges[n-1].Text
+			}
+		})
+	case "textDocument/didSave":
+		var p DidSaveTextDocumentParams
+		if err := decode(msg, &p); err != nil {
+			return nil, err
+		}
+		return nil, s.update(p.TextDocument.URI, true, func(string) {})
+	case "textDocument/didClose":
+		var p DidCloseTextDocumentParams
+		if err := decode(msg, &p); err != nil {
+			return nil, err
+		}
+		return nil, s.update(p.TextDocument.URI, false, func(file string) {
+			delete(s.open, file)
+		})
+
+	case "textDocument/completion":
+		return s.position(msg, s.completion)
+	case "textDocument/definition":
+		return s.position(msg, s.definition)
+	case "textDocument/hover":
+		return s.position(msg, s.hover)
+	}
+	if msg.ID == nil {
+		return nil, nil
+	}
+	return nil, &ResponseError{CODE_METHOD_NOT_FOUND, fmt.Sprintf("method %q not supported", msg.Method)}
+}
+
+func decode(msg *Message, params interface{}) error {
+	err := json.Unmarshal(msg.Params, params)
+	if err != nil {
+		return &ResponseError{CODE_INVALID_PARAMS, err.Error()}
+	}
+	return nil
+}
+
+// update updates the document with the given URI
+// and reprocesses the source tree.
+func (s *server) update(uri string, generate bool, mod func(file string)) error {
+	file, err := FilePath(uri)
+	if err != nil {
+		return &ResponseError{CODE_INVALID_PARAMS, err.Error()}
+	}
+	mod(file)
+	return s.reload(generate)
+}
+
+// reload reprocesses the source tree and publishes the found diagnostics.
+func (s *server) reload(generate bool) error {
+	t, err := s.load(s.filesystem(), generate)
+	if t != nil {
+		s.tree = t
+	}
+	return s.publish(err)
+}
+
+// filesystem provides the filesystem with the content of the
+// opened documents laid over the base filesystem.
+func (s *server) filesystem() vfs.FileSystem {
+	if len(s.open) == 0 {
+		return s.fs
+	}
+	fs := layerfs.New(memoryfs.New(), s.fs)
+	for _, file := range utils.StringMapKeys(s.open) {
+		err := fs.MkdirAll(path.Dir(file), 0o755)
+		if err == nil {
+			err = vfs.WriteFile(fs, file, []byte(s.open[file]), 0o644)
+		}
+		if err != nil {
+			s.log.Errorf("cannot provide document %s: %s", file, err)
+		}
+	}
+	return fs
+}
+
+// content provides the content of a document.
+func (s *server) content(file string) string {
+	if c, ok := s.open[file]; ok {
+		return c
+	}
+	data, err := vfs.ReadFile(s.fs, file)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// publish publishes the diagnostics described by an error grouped by
+// their documents. Documents without remaining diagnostics are cleared.
+func (s *server) publish(err error) error {
+	found := map[string][]Diagnostic{}
+	for _, d := range diagnostics.List(err) {
+		if d.File == "" {
+			err := s.conn.Notify("window/logMessage", &LogMessageParams{MESSAGE_ERROR, d.Error()})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		file, _ := filepath.Abs(d.File)
+		severity := SEVERITY_ERROR
+		if d.Severity == diagnostics.SEVERITY_WARNING {
+			severity = SEVERITY_WARNING
+		}
+		found[file] = append(found[file], Diagnostic{
+			Range:    s.rangeAt(file, d.Line, d.Column),
+			Severity: severity,
+			Code:     d.Code,
+			Source:   "mdgen",
+			Message:  d.Message,
+		})
+	}
+
+	files := utils.StringMapKeys(found)
+	for _, file := range utils.SortedMapKeys(s.published) {
+		if found[file] == nil {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	s.published = utils.Set[string]{}
+	for _, file := range files {
+		list := found[file]
+		if list == nil {
+			list = []Diagnostic{}
+		} else {
+			s.published.Add(file)
+		}
+		err := s.conn.Notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{FileURI(file), list})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rangeAt provides the range for a 1-based line and column of a document.
+// It covers the complete statement or word found at this position.
+func (s *server) rangeAt(file string, line, column int) Range {
+	if line <= 0 {
+		return Range{}
+	}
+	text := ""
+	if l := lines(s.content(file)); line <= len(l) {
+		text = l[line-1]
+	}
+	runes := []rune(text)
+	start := column - 1
+	if start < 0 || start > len(runes) {
+		start = 0
+	}
+	end := start
+	if end+1 < len(runes) && runes[end] == '{' && runes[end+1] == '{' {
+		for end+1 < len(runes) && !(runes[end] == '}' && runes[end+1] == '}') {
+			end++
+		}
+		end += 2
+		if end > len(runes) {
+			end = len(runes)
+		}
+	} else {
+		for end < len(runes) && runes[end] != ' ' && runes[end] != '\t' {
+			end++
+		}
+	}
+	return Range{
+		Start: Position{line - 1, characterOffset(text, start)},
+		End:   Position{line - 1, characterOffset(text, end)},
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type positionHandler func(file string, pos Position, text string, info *StatementInfo) (interface{}, error)
+
+// position decodes the parameters of a position based request and
+// determines the statement found at this position.
+func (s *server) position(msg *Message, h positionHandler) (interface{}, error) {
+	var p TextDocumentPositionParams
+	err := decode(msg, &p)
+	if err != nil {
+		return nil, err
+	}
+	file, err := FilePath(p.TextDocument.URI)
+	if err != nil {
+		return nil, &ResponseError{CODE_INVALID_PARAMS, err.Error()}
+	}
+	l := lines(s.content(file))
+	if p.Position.Line < 0 || p.Position.Line >= len(l) {
+		return nil, nil
+	}
+	text := l[p.Position.Line]
+	info := StatementAt(text, runeOffset(text, p.Position.Character))
+	if info == nil || s.tree == nil {
+		return nil, nil
+	}
+	return h(file, p.Position, text, info)
+}
+
+func (s *server) completion(file string, pos Position, text string, info *StatementInfo) (interface{}, error) {
+	edit := Range{
+		Start: Position{pos.Line, characterOffset(text, info.Start)},
+		End:   Position{pos.Line, characterOffset(text, info.End)},
+	}
+
+	var names []string
+	kind := COMPLETION_REFERENCE
+	if info.OnTag {
+		names = s.tree.Candidates(file, info.Keyword, pos.Line+1, runeOffset(text, pos.Character)+1)
+	} else {
+		names = utils.SortedMapKeys(utils.Set[string]{}.Add(scanner.Tokens.Names()...).Add(utils.StringMapKeys(scanner.Keywords)...))
+		kind = COMPLETION_KEYWORD
+	}
+	items := []CompletionItem{}
+	for _, n := range names {
+		items = append(items, CompletionItem{
+			Label:    n,
+			Kind:     kind,
+			TextEdit: &TextEdit{Range: edit, NewText: n},
+		})
+	}
+	return items, nil
+}
+
+func (s *server) definition(file string, pos Position, text string, info *StatementInfo) (interface{}, error) {
+	sym := s.symbol(file, info)
+	if sym == nil {
+		return nil, nil
+	}
+	target, _ := filepath.Abs(sym.Location.Source())
+	return &Location{
+		URI:   FileURI(target),
+		Range: s.rangeAt(target, sym.Location.Line(), sym.Location.Column()),
+	}, nil
+}
+
+func (s *server) hover(file string, pos Position, text string, info *StatementInfo) (interface{}, error) {
+	sym := s.symbol(file, info)
+	if sym == nil {
+		return nil, nil
+	}
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: sym.Description},
+		Range: &Range{
+			Start: Position{pos.Line, characterOffset(text, info.Start)},
+			End:   Position{pos.Line, characterOffset(text, info.End)},
+		},
+	}, nil
+}
+
+func (s *server) symbol(file string, info *StatementInfo) *tree.Symbol {
+	if !info.OnTag || info.Tag == "" {
+		return nil
+	}
+	return s.tree.Definition(file, info.Keyword, info.Tag)
+}
@@ -0,0 +1,141 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package lsp
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// tagPrefixes are the flag characters a statement accepts
+// in front of its tag.
+var tagPrefixes = map[string]string{
+	"term":       "!#*^",
+	"link":       "*^",
+	"ref":        "*^",
+	"sectionref": "*^",
+	"title":      "*^",
+	"label":      "*^",
+	"toc":        "*^",
+}
+
+// StatementInfo describes the statement found at a position of a line.
+// Start and End describe the rune range of the keyword or the tag
+// the position is located in, the tag excludes the flag characters
+// of the statement.
+type StatementInfo struct {
+	Keyword string
+	Tag     string
+	OnTag   bool
+	Start   int
+	End     int
+}
+
+// StatementAt analyses the statement found in a line at the given
+// rune position. If the position is not located in the keyword or
+// the tag of a statement, nil is returned.
+func StatementAt(line string, pos int) *StatementInfo {
+	runes := []rune(line)
+	if pos > len(runes) {
+		pos = len(runes)
+	}
+
+	start := -1
+	for i := pos - 2; i >= 0; i-- {
+		if runes[i] == '}' && runes[i+1] == '}' {
+			return nil
+		}
+		if runes[i] == '{' && runes[i+1] == '{' {
+			if i > 0 && runes[i-1] == '\\' {
+				return nil
+			}
+			start = i + 2
+			break
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+
+	i := skipSpace(runes, start)
+	if i < len(runes) && runes[i] == '*' {
+		i++
+	}
+	kstart := i
+	for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+		i++
+	}
+	info := &StatementInfo{Keyword: string(runes[kstart:i])}
+	if pos >= kstart && pos <= i {
+		info.Start, info.End = kstart, i
+		info.Keyword = string(runes[kstart:pos])
+		return info
+	}
+	if info.Keyword == "" || i == len(runes) || !unicode.IsSpace(runes[i]) {
+		return nil
+	}
+
+	i = skipSpace(runes, i)
+	if i < len(runes) && runes[i] == '"' {
+		i++
+	}
+	for i < len(runes) && strings.ContainsRune(tagPrefixes[info.Keyword], runes[i]) {
+		i++
+	}
+	tstart := i
+	for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '"' && !strings.HasPrefix(string(runes[i:]), "}}") {
+		i++
+	}
+	if info.Keyword == "blockref" {
+		// the block may be prefixed by the name of a statement
+		// (<statement>:<block>)
+		for j := tstart; j < i; j++ {
+			if runes[j] == ':' {
+				tstart = j + 1
+				break
+			}
+		}
+	}
+	if pos < tstart || pos > i {
+		return nil
+	}
+	info.OnTag = true
+	info.Tag = string(runes[tstart:i])
+	info.Start, info.End = tstart, i
+	return info
+}
+
+func skipSpace(runes []rune, i int) int {
+	for i < len(runes) && unicode.IsSpace(runes[i]) {
+		i++
+	}
+	return i
+}
+
+// runeOffset converts a UTF-16 based character offset
+// of a line to a rune offset.
+func runeOffset(line string, character int) int {
+	n := 0
+	for i, r := range []rune(line) {
+		if n >= character {
+			return i
+		}
+		n += len(utf16.Encode([]rune{r}))
+	}
+	return len([]rune(line))
+}
+
+// characterOffset converts a rune offset of a line
+// to a UTF-16 based character offset.
+func characterOffset(line string, offset int) int {
+	runes := []rune(line)
+	if offset > len(runes) {
+		offset = len(runes)
+	}
+	return len(utf16.Encode(runes[:offset]))
+}
@@ -7,50 +7,359 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/mdgen/config"
+	"github.com/mandelsoft/mdgen/diagnostics"
+	"github.com/mandelsoft/mdgen/logging"
+	"github.com/mandelsoft/mdgen/render"
+	"github.com/mandelsoft/mdgen/scanner"
 	"github.com/mandelsoft/mdgen/tree"
+	"github.com/mandelsoft/mdgen/utils"
 	"github.com/mandelsoft/mdgen/version"
 )
 
-func Tree() {
-	print := false
-	copy := false
-	args := os.Args[1:]
-	if len(args) > 0 {
-		if args[0] == "--version" {
-			info := version.Get()
+// Options describes the processing options for a source tree.
+type Options struct {
+	Copy   bool
+	Prune  bool
+	Print  bool
+	Single bool
 
-			fmt.Printf("mdgen version %s.%s.%s (%s) [%s %s]\n", info.Major, info.Minor, info.Patch, info.PreRelease, info.GitTreeState, info.GitCommit)
-			os.Exit(0)
+	FrontMatter bool
+	Inventory   bool
+	Search      bool
+	Logger      logging.Logger
+	FS          vfs.FileSystem
+
+	// Config is the used project configuration file.
+	Config string
+	// InventoryCache caches the imported reference inventories
+	// by their location, if set.
+	InventoryCache map[string]*tree.Inventory
+
+	Include      []string
+	Exclude      []string
+	NumberRanges scanner.LabelRules
+	Values       map[string]string
+	Inventories  map[string]config.InventoryImport
+	Flavour      string
+	Format       string
+	EndStyle     string
+}
+
+// Command describes a sub command of the mdgen command line.
+// Setup declares the flags of the command and provides the function
+// executing the command for the given positional arguments.
+type Command struct {
+	Name  string
+	Args  string
+	Short string
+	Long  string
+	Setup func(fs *flag.FlagSet) func(args []string) error
+}
+
+var commands []*Command
+
+func init() {
+	commands = []*Command{
+		generateCmd,
+		checkCmd,
+		graphCmd,
+		refsCmd,
+		navCmd,
+		exportCmd,
+		lspCmd,
+		fmtCmd,
+		importCmd,
+		initCmd,
+		versionCmd,
+	}
+}
+
+// DefaultCommand is used if no sub command is given.
+const DefaultCommand = "generate"
+
+func lookupCommand(name string) *Command {
+	for _, c := range commands {
+		if c.Name == name {
+			return c
 		}
+	}
+	return nil
+}
 
-		if args[0] == "--help" {
-			fmt.Printf("mdgen [--doc] [--copy] [<source dir> [<target dir>]]\n")
-			fmt.Printf(`
-Flags:
-  --doc   print doc graph
-  --copy  copy used resources into target tree
+// FlagSet creates the flag set for a command. The usage
+// output describes the command with its flags.
+func (c *Command) FlagSet() (*flag.FlagSet, func(args []string) error) {
+	fs := flag.NewFlagSet("mdgen "+c.Name, flag.ContinueOnError)
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintf(w, "usage: mdgen %s [<options>] %s\n\n%s\n", c.Name, c.Args, strings.TrimSpace(c.Long))
+		fmt.Fprintf(w, "\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	return fs, c.Setup(fs)
+}
 
-mdgen generated GitHub consistently interlinked markdown files for a tree of mdg
-source files (see https://github.com/mandelsoft/mdgen).
-`)
-			os.Exit(0)
+// Run parses the arguments and executes the command.
+func (c *Command) Run(args []string) error {
+	fs, run := c.FlagSet()
+	args, err := Parse(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
 		}
-		if args[0] == "--doc" {
-			print = true
-			args = args[1:]
+		// the flag set already reported the error together with the usage
+		return errUsage
+	}
+	return run(args)
+}
+
+// Parse parses the flags of a command. Flags may be mixed with
+// positional arguments, all arguments following -- are treated as
+// positional arguments. The positional arguments are returned.
+func Parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var pos []string
+	for len(args) > 0 {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, err
 		}
-		if args[0] == "--copy" {
-			copy = true
+		rest := fs.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(pos, rest...), nil
+		}
+		if len(rest) == 0 {
+			break
+		}
+		pos = append(pos, rest[0])
+		args = rest[1:]
+	}
+	return pos, nil
+}
+
+func Tree(args []string) int {
+	cmd := lookupCommand(DefaultCommand)
+	if len(args) > 0 {
+		switch args[0] {
+		case "help", "--help", "-h", "-help":
+			return Help(args[1:])
+		case "--version":
+			args = append([]string{"version"}, args[1:]...)
+		}
+		if c := lookupCommand(args[0]); c != nil {
+			cmd = c
 			args = args[1:]
 		}
 	}
-	if len(args) > 2 {
-		fmt.Printf("use mdgen [--doc] [<source> [<target>]]")
-		os.Exit(1)
+
+	err := cmd.Run(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		var uerr usageError
+		if errors.As(err, &uerr) {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			fmt.Fprintf(os.Stderr, "use mdgen help %s for a description of the command\n", cmd.Name)
+			return 2
+		}
+		if !errors.Is(err, errReported) {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		}
+		return 1
+	}
+	return 0
+}
+
+// Help prints the usage of mdgen or a command.
+func Help(args []string) int {
+	if len(args) > 0 {
+		c := lookupCommand(args[0])
+		if c == nil {
+			fmt.Fprintf(os.Stderr, "Error: unknown command %q\n", args[0])
+			return 2
+		}
+		fs, _ := c.FlagSet()
+		fs.SetOutput(os.Stdout)
+		fs.Usage()
+		return 0
+	}
+	fmt.Printf(`usage: mdgen [<command>] [<options>] [<args>]
+
+mdgen generates consistently interlinked GitHub markdown files for a tree of mdg
+source files (see https://github.com/mandelsoft/mdgen).
+
+Commands:
+`)
+	for _, c := range commands {
+		fmt.Printf("  %-9s %s\n", c.Name, c.Short)
+	}
+	fmt.Printf(`
+If no command is given, %s is used.
+Use mdgen help <command> for a description of a command and its options.
+`, DefaultCommand)
+	return 0
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+func usageErrorf(msg string, args ...interface{}) error {
+	return usageError{fmt.Sprintf(msg, args...)}
+}
+
+// errReported is returned by commands, which already
+// reported their errors.
+var errReported = errors.New("reported")
+
+// errUsage is returned for invalid flags already reported
+// by the flag set.
+var errUsage = errors.New("usage")
+
+////////////////////////////////////////////////////////////////////////////////
+
+// verbosity is a flag value counting the number of
+// occurrences of a boolean flag.
+type verbosity int
+
+func (v *verbosity) String() string {
+	if v == nil {
+		return "0"
+	}
+	return strconv.Itoa(int(*v))
+}
+
+func (v *verbosity) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
 	}
+	if b {
+		*v++
+	}
+	return nil
+}
+
+func (v *verbosity) IsBoolFlag() bool {
+	return true
+}
+
+// CommonFlags describes the flags used by all commands
+// processing a source tree.
+type CommonFlags struct {
+	flags      *flag.FlagSet
+	quiet      bool
+	verbose    verbosity
+	debug      bool
+	logformat  string
+	diagformat string
+	config     string
+	flavour    string
+	format     string
+	copy       bool
+	single     bool
+	fmatter    bool
+	inventory  bool
+	search     bool
+}
+
+func (f *CommonFlags) AddFlags(fs *flag.FlagSet) {
+	f.flags = fs
+	fs.BoolVar(&f.quiet, "q", false, "quiet mode, report errors only")
+	fs.Var(&f.verbose, "v", "verbose mode, report progress (given twice for debug output)")
+	fs.BoolVar(&f.debug, "vv", false, "debug mode, report detailed progress")
+	fs.StringVar(&f.logformat, "log-format", string(logging.FORMAT_TEXT), "format for log output (text or json)")
+	fs.StringVar(&f.diagformat, "diagnostics", "text", "format for reported errors (text or json)")
+	fs.StringVar(&f.config, "config", "", "project configuration file (default: "+config.CONFIG_FILE+" in source folder)")
+	fs.StringVar(&f.flavour, "flavour", "", "output flavour ("+strings.Join(render.Names(), ", ")+")")
+	fs.BoolVar(&f.copy, "copy", false, "copy used resources into target tree")
+	fs.BoolVar(&f.single, "single", false, "generate a single document for every root document")
+	fs.BoolVar(&f.fmatter, "frontmatter", false, "prefix generated documents with YAML front matter")
+}
+
+// AddFormatFlag adds the flag for the output format for commands
+// generating a target tree.
+func (f *CommonFlags) AddFormatFlag(fs *flag.FlagSet) {
+	fs.StringVar(&f.format, "format", "", "output format ("+strings.Join(config.Formats, ", ")+", default: "+config.FORMAT_MARKDOWN+")")
+}
+
+// AddIndexFlags adds the flags for the generation of the reference
+// inventory and the search index for commands generating or checking
+// a target tree.
+func (f *CommonFlags) AddIndexFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&f.inventory, "inventory", false, "write a reference inventory ("+tree.INVENTORY+") into the target tree")
+	fs.BoolVar(&f.search, "search", false, "write a search index ("+tree.SEARCH_INDEX+") into the target tree")
+}
+
+// IsSet checks whether a flag has explicitly been given
+// on the command line.
+func (f *CommonFlags) IsSet(name string) bool {
+	return f.explicit().Has(name)
+}
+
+// explicit provides the names of the flags explicitly
+// given on the command line.
+func (f *CommonFlags) explicit() utils.Set[string] {
+	set := utils.Set[string]{}
+	if f.flags != nil {
+		f.flags.Visit(func(fl *flag.Flag) {
+			set.Add(fl.Name)
+		})
+	}
+	return set
+}
+
+// Options evaluates the common flags and the project configuration
+// and provides the processing options and the effective source and target
+// folder. The given positional arguments are the optional source
+// and target folder. They override the configuration.
+func (f *CommonFlags) Options(args []string) (Options, string, string, error) {
+	var opts Options
+
+	level := logging.DefaultLevel
+	switch {
+	case f.quiet:
+		level = logging.ErrorLevel
+	case f.debug || f.verbose > 1:
+		level = logging.DebugLevel
+	case f.verbose > 0:
+		level = logging.InfoLevel
+	}
+	format, err := logging.ParseFormat(f.logformat)
+	if err != nil {
+		return opts, "", "", usageError{err.Error()}
+	}
+	if f.diagformat != "text" && f.diagformat != "json" {
+		return opts, "", "", usageErrorf("diagnostics format text or json required")
+	}
+	opts.Logger = logging.New(os.Stderr, level, format)
+	opts.Copy = f.copy
+	opts.Single = f.single
+	opts.FrontMatter = f.fmatter
+	opts.Inventory = f.inventory
+	opts.Search = f.search
+	opts.Flavour = f.flavour
+	opts.Format = f.format
+
 	src := "."
 	if len(args) > 0 {
 		src = args[0]
@@ -59,35 +368,182 @@ source files (see https://github.com/mandelsoft/mdgen).
 	if len(args) > 1 {
 		dst = args[1]
 	}
-	t, err := tree.ForFolder(src)
+
+	cfgfile := f.config
+	if cfgfile == "" {
+		cfgfile, err = config.Lookup([]string{src})
+		if err != nil {
+			return opts, "", "", err
+		}
+	}
+	if cfgfile != "" {
+		cfg, err := config.Read(cfgfile)
+		if err == nil {
+			err = ApplyConfig(cfgfile, cfg, &opts, f.explicit())
+		}
+		if err != nil {
+			return opts, "", "", f.Report(err)
+		}
+		if len(args) == 0 && cfg.Source != "" {
+			src = cfg.Source
+		}
+		if len(args) < 2 && cfg.Target != "" {
+			dst = cfg.Target
+		}
+		opts.Config = cfgfile
+		opts.Logger.Infof("using config %s", cfgfile)
+	}
+	err = (&config.Config{Flavour: opts.Flavour, Format: opts.Format}).Validate()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-		os.Exit(1)
+		return opts, "", "", usageError{err.Error()}
 	}
-	t.SetCopyMode(copy)
-	if print {
-		t.Print("")
+	if opts.Format == "" {
+		opts.Format = config.FORMAT_MARKDOWN
 	}
+	if opts.FrontMatter && opts.Format != config.FORMAT_MARKDOWN {
+		return opts, "", "", usageErrorf("front matter is only supported for %s output", config.FORMAT_MARKDOWN)
+	}
+	return opts, src, dst, nil
+}
 
-	err = t.Resolve()
+// Report reports a processing error according to the
+// selected diagnostics format.
+func (f *CommonFlags) Report(err error) error {
+	ReportError(err, f.diagformat)
+	return errReported
+}
+
+// ApplyConfig applies the settings of a project configuration
+// to the processing options. Options set by command line flags
+// explicitly given (set) are kept.
+func ApplyConfig(file string, cfg *config.Config, opts *Options, set utils.Set[string]) error {
+	apply := func(o *bool, name string, v bool) {
+		if !set.Has(name) {
+			*o = v
+		}
+	}
+	apply(&opts.Copy, "copy", cfg.Copy)
+	apply(&opts.Prune, "prune", cfg.Prune)
+	apply(&opts.Single, "single", cfg.Single)
+	apply(&opts.FrontMatter, "frontmatter", cfg.FrontMatter)
+	apply(&opts.Inventory, "inventory", cfg.Inventory)
+	apply(&opts.Search, "search", cfg.Search)
+	opts.Inventories = cfg.Inventories
+	if opts.Flavour == "" {
+		opts.Flavour = cfg.Flavour
+	}
+	if opts.Format == "" {
+		opts.Format = cfg.Format
+	}
+	if opts.EndStyle == "" {
+		opts.EndStyle = cfg.EndStyle
+	}
+	opts.Include = cfg.Include
+	opts.Exclude = cfg.Exclude
+	opts.Values = cfg.Values
+	if len(cfg.NumberRanges) > 0 {
+		rules, err := scanner.ParseLabelRules(file, cfg.NumberRanges)
+		if err != nil {
+			return diagnostics.WithCode(err, diagnostics.CODE_DEFINITION)
+		}
+		opts.NumberRanges = rules
+	}
+	return nil
+}
+
+// ReportError reports a processing error. For the json format
+// the list of diagnostics described by the error is written to
+// stdout as JSON array.
+func ReportError(err error, format string) {
+	if format == "json" {
+		diagnostics.WriteJSON(os.Stdout, diagnostics.List(err))
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+}
+
+// Load scans and resolves the source folder. If the resolution fails,
+// the partially resolved tree is returned together with the error.
+func Load(src string, opts Options) (tree.Tree, error) {
+	t, err := tree.ForFolderWithOptions(src, tree.ScanOptions{
+		Logger:  utils.OptionalDefaulted(logging.Default(), opts.Logger),
+		Include: opts.Include,
+		Exclude: opts.Exclude,
+	}, opts.FS)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-		os.Exit(1)
+		return nil, err
+	}
+	t.SetCopyMode(opts.Copy)
+	t.SetSingleFile(opts.Single)
+	t.SetFrontMatter(opts.FrontMatter)
+	t.SetInventory(opts.Inventory)
+	t.SetSearchIndex(opts.Search)
+	for _, prefix := range utils.StringMapKeys(opts.Inventories) {
+		imp := opts.Inventories[prefix]
+		location := imp.Inventory
+		if location == "" {
+			location = strings.TrimSuffix(imp.URL, "/") + "/" + tree.INVENTORY
+		}
+		inv := opts.InventoryCache[location]
+		if inv == nil {
+			inv, err = tree.ReadInventory(location, opts.FS)
+			if err != nil {
+				return nil, err
+			}
+			if opts.InventoryCache != nil {
+				opts.InventoryCache[location] = inv
+			}
+		}
+		t.ImportInventory(prefix, imp.URL, inv)
+	}
+	t.SetNumberRangeDefaults(opts.NumberRanges)
+	t.SetValues(opts.Values)
+	if opts.Flavour != "" {
+		r, err := render.Get(opts.Flavour)
+		if err != nil {
+			return nil, err
+		}
+		t.SetRenderer(r)
+	}
+	if opts.Print {
+		t.Print("")
 	}
 
-	tw, err := tree.NewFileTreeWriter(dst)
+	err = t.Resolve()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-		os.Exit(1)
+		return t, err
 	}
-	tw.Close()
-	err = t.Emit(tw)
+	return t, nil
+}
+
+// Emit processes the source folder and emits the result to the given
+// tree writer.
+func Emit(src string, tw tree.TreeWriter, opts Options) error {
+	t, err := Load(src, opts)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-		os.Exit(1)
+		return err
 	}
+	return t.Emit(tw)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+var versionCmd = &Command{
+	Name:  "version",
+	Short: "print the version of mdgen",
+	Long:  "Print the version of mdgen.",
+	Setup: func(fs *flag.FlagSet) func(args []string) error {
+		return func(args []string) error {
+			if len(args) > 0 {
+				return usageErrorf("no arguments expected")
+			}
+			info := version.Get()
+			fmt.Printf("mdgen version %s.%s.%s (%s) [%s %s]\n", info.Major, info.Minor, info.Patch, info.PreRelease, info.GitTreeState, info.GitCommit)
+			return nil
+		}
+	},
 }
 
 func main() {
-	Tree()
+	os.Exit(Tree(os.Args[1:]))
 }
@@ -0,0 +1,82 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/mandelsoft/mdgen/tree"
+)
+
+var navCmd = &Command{
+	Name:  "nav",
+	Args:  "[<source>]",
+	Short: "generate a navigation file for static site generators",
+	Long: `
+Resolve the source tree and generate a navigation definition from its
+document structure: the root documents and their structural sub documents
+with their section labels and titles. Supported formats are the nav
+section of an MkDocs configuration (mkdocs) and a Docusaurus sidebar
+definition (docusaurus).
+
+The navigation is written to stdout or to the file given with --output.
+If this file already exists, only the navigation is replaced and all other
+content is kept.
+`,
+	Setup: func(fs *flag.FlagSet) func(args []string) error {
+		var flags CommonFlags
+		var format, output, sidebar string
+
+		flags.AddFlags(fs)
+		fs.StringVar(&format, "format", tree.NAV_MKDOCS, "navigation format ("+strings.Join(tree.NavFormats, ", ")+")")
+		fs.StringVar(&output, "output", "", "navigation file to create or update")
+		fs.StringVar(&sidebar, "sidebar", "docs", "name of the Docusaurus sidebar")
+
+		return func(args []string) error {
+			if len(args) > 1 {
+				return usageErrorf("at most a source folder expected")
+			}
+			if format != tree.NAV_MKDOCS && format != tree.NAV_DOCUSAURUS {
+				return usageErrorf("unknown navigation format %q (use one of %s)", format, strings.Join(tree.NavFormats, ", "))
+			}
+			opts, src, _, err := flags.Options(args)
+			if err != nil {
+				return err
+			}
+			t, err := Load(src, opts)
+			if err != nil {
+				return flags.Report(err)
+			}
+
+			var existing []byte
+			if output != "" {
+				existing, err = os.ReadFile(output)
+				if err != nil && !errors.Is(err, os.ErrNotExist) {
+					return err
+				}
+			}
+			var data []byte
+			switch format {
+			case tree.NAV_DOCUSAURUS:
+				data, err = tree.DocusaurusSidebars(t.DocumentStructure(), sidebar, existing)
+			default:
+				data, err = tree.MkDocsNav(t.DocumentStructure(), existing)
+			}
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				_, err = os.Stdout.Write(data)
+				return err
+			}
+			return os.WriteFile(output, data, 0o644)
+		}
+	},
+}
@@ -0,0 +1,109 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FLAVOUR_COMMONMARK generates pure CommonMark without embedded HTML.
+// Because CommonMark does not support explicit anchors, links to
+// anchors use the anchors generated by the markdown viewer for
+// the headings (see HeadingAnchors).
+const FLAVOUR_COMMONMARK = "commonmark"
+
+func init() {
+	Register(&commonmarkRenderer{})
+}
+
+type commonmarkRenderer struct{}
+
+var _ HeadingAnchors = (*commonmarkRenderer)(nil)
+
+func (r *commonmarkRenderer) Name() string {
+	return FLAVOUR_COMMONMARK
+}
+
+func (r *commonmarkRenderer) Link(w io.Writer, link string, content func() error) error {
+	fmt.Fprintf(w, "[")
+	err := content()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "](%s)", link)
+	return nil
+}
+
+func (r *commonmarkRenderer) Anchors(w io.Writer, anchors []string) {
+}
+
+func (r *commonmarkRenderer) HeadingAnchor(text string) string {
+	return Slug(text)
+}
+
+func (r *commonmarkRenderer) Heading(w io.Writer, level int, text string) {
+	fmt.Fprintf(w, "%s %s\n", strings.Repeat("#", level), text)
+}
+
+func (r *commonmarkRenderer) Caption(w io.Writer, text string) {
+	fmt.Fprintf(w, "\n*%s*\n\n", strings.TrimSpace(text))
+}
+
+func (r *commonmarkRenderer) Center(w io.Writer, content func() error) error {
+	return content()
+}
+
+func (r *commonmarkRenderer) Labeled(w io.Writer, box bool, content func() error, caption func() error) error {
+	err := content()
+	if err != nil {
+		return err
+	}
+	return caption()
+}
+
+func (r *commonmarkRenderer) Figure(w io.Writer, src, alt string, attrs []string, caption func() error) error {
+	fmt.Fprintf(w, "![%s](%s)\n", alt, src)
+	return caption()
+}
+
+func (r *commonmarkRenderer) TOCEntry(w io.Writer, level int, text, link string) {
+	fmt.Fprintf(w, "%s- [%s](%s)\n", strings.Repeat("  ", level), text, link)
+}
+
+func (r *commonmarkRenderer) IndexBar(w io.Writer, entries []LinkEntry) {
+	var list []string
+	for _, e := range entries {
+		if e.Link == "" {
+			list = append(list, e.Text)
+		} else {
+			list = append(list, fmt.Sprintf("[%s](%s)", e.Text, e.Link))
+		}
+	}
+	fmt.Fprintf(w, "%s\n\n", strings.Join(list, " | "))
+}
+
+func (r *commonmarkRenderer) GlossaryEntry(w io.Writer, text, link, anchor string) {
+	fmt.Fprintf(w, "### [%s](%s)\n", text, link)
+}
+
+func (r *commonmarkRenderer) Trail(w io.Writer, entries []LinkEntry) {
+	var list []string
+	for _, e := range entries {
+		list = append(list, fmt.Sprintf("[%s](%s)", e.Text, e.Link))
+	}
+	fmt.Fprintf(w, "%s\n\n---\n\n", strings.Join(list, " → "))
+}
+
+func (r *commonmarkRenderer) Lines(w io.Writer, lines []string) {
+	fmt.Fprintf(w, "\n%s\n\n", strings.Join(lines, r.LineBreak()))
+}
+
+func (r *commonmarkRenderer) LineBreak() string {
+	return "\\\n"
+}
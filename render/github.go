@@ -0,0 +1,135 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FLAVOUR_GITHUB generates markdown for GitHub using embedded HTML
+// for anchors, centered content and links.
+const FLAVOUR_GITHUB = "github"
+
+var github Renderer = &githubRenderer{}
+
+func init() {
+	Register(github)
+}
+
+type githubRenderer struct{}
+
+func (r *githubRenderer) Name() string {
+	return FLAVOUR_GITHUB
+}
+
+func (r *githubRenderer) Link(w io.Writer, link string, content func() error) error {
+	fmt.Fprintf(w, "<a href=\"%s\">", link)
+	err := content()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "</a>")
+	return nil
+}
+
+func (r *githubRenderer) Anchors(w io.Writer, anchors []string) {
+	if len(anchors) > 0 {
+		fmt.Fprintf(w, "<a/>") // without at least two <a> github cannot render sections anymore
+		for _, a := range anchors {
+			fmt.Fprintf(w, "<a id=\"%s\"/>", a)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+func (r *githubRenderer) Heading(w io.Writer, level int, text string) {
+	fmt.Fprintf(w, "%s %s\n", strings.Repeat("#", level), text)
+}
+
+func (r *githubRenderer) Caption(w io.Writer, text string) {
+	fmt.Fprintf(w, " %s\n</br></br>\n", text)
+}
+
+func (r *githubRenderer) Center(w io.Writer, content func() error) error {
+	fmt.Fprintf(w, "<div align=\"center\">\n\n")
+	err := content()
+	fmt.Fprintf(w, "</div>\n")
+	return err
+}
+
+func (r *githubRenderer) Labeled(w io.Writer, box bool, content func() error, caption func() error) error {
+	if box {
+		fmt.Fprintf(w, "<div align=\"center\"><table><tr><td>\n\n")
+	}
+	err := content()
+	if err != nil {
+		return err
+	}
+	if box {
+		fmt.Fprintf(w, "</td></tr></table>\n")
+	} else {
+		fmt.Fprintf(w, "<div align=\"center\">\n")
+	}
+	err = caption()
+	fmt.Fprintf(w, "</div>\n")
+	return err
+}
+
+func (r *githubRenderer) Figure(w io.Writer, src, alt string, attrs []string, caption func() error) error {
+	fmt.Fprintf(w, "<div align=\"center\">\n")
+	fmt.Fprintf(w, "<img src=\"%s\" alt=\"%s\" %s/>\n", src, alt, strings.Join(attrs, " "))
+	err := caption()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "</div>\n")
+	return nil
+}
+
+func (r *githubRenderer) TOCEntry(w io.Writer, level int, text, link string) {
+	gap := strings.Repeat("&nbsp;&nbsp;", level*2+2)
+	fmt.Fprintf(w, "%s [%s](%s)<br>\n", gap, text, link)
+}
+
+func (r *githubRenderer) IndexBar(w io.Writer, entries []LinkEntry) {
+	for _, e := range entries {
+		if e.Link == "" {
+			fmt.Fprintf(w, "%s &nbsp;", e.Text)
+		} else {
+			fmt.Fprintf(w, "[%s](%s) &nbsp;", e.Text, e.Link)
+		}
+	}
+	fmt.Fprintf(w, "\n\n")
+}
+
+func (r *githubRenderer) GlossaryEntry(w io.Writer, text, link, anchor string) {
+	fmt.Fprintf(w, "### [%s](%s)<a id=\"%s\"/>\n", text, link, anchor)
+}
+
+func (r *githubRenderer) Trail(w io.Writer, entries []LinkEntry) {
+	for i, e := range entries {
+		if i > 0 {
+			fmt.Fprintf(w, "&nbsp;&#10230;&nbsp;")
+		}
+		fmt.Fprintf(w, "[%s](%s)", e.Text, e.Link)
+	}
+	fmt.Fprintf(w, "\n\n---\n\n")
+}
+
+func (r *githubRenderer) Lines(w io.Writer, lines []string) {
+	fmt.Fprintf(w, "<div>\n\n")
+	for _, l := range lines {
+		fmt.Fprintf(w, "%s</br>\n", l)
+	}
+	fmt.Fprintf(w, "</div>\n")
+}
+
+func (r *githubRenderer) LineBreak() string {
+	return "</br>"
+}
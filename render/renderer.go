@@ -8,33 +8,100 @@ package render
 
 import (
 	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// LinkEntry describes a linked text. An entry with an empty link
+// is rendered without a link.
+type LinkEntry struct {
+	Text string
+	Link string
+}
+
+// Renderer is used by the statements to emit the markup for
+// all document elements. Its implementations describe the supported
+// output flavours.
+type Renderer interface {
+	// Name provides the flavour name of the renderer.
+	Name() string
+
+	// Link emits a link. The link text is emitted by the content function.
+	Link(w io.Writer, link string, content func() error) error
+	// Anchors emits the anchor definitions for a referencable element.
+	Anchors(w io.Writer, anchors []string)
+	// Heading emits a heading with the given level (starting with 1).
+	Heading(w io.Writer, level int, text string)
+	// Caption emits the title of a labeled element.
+	Caption(w io.Writer, text string)
+	// Center emits centered content.
+	Center(w io.Writer, content func() error) error
+	// Labeled emits the content of a labeled element (optionally boxed)
+	// followed by its caption.
+	Labeled(w io.Writer, box bool, content func() error, caption func() error) error
+	// Figure emits an image followed by its caption.
+	Figure(w io.Writer, src, alt string, attrs []string, caption func() error) error
+	// TOCEntry emits an entry of a table of contents with the
+	// given nesting level (starting with 0).
+	TOCEntry(w io.Writer, level int, text, link string)
+	// IndexBar emits a bar of index entries, for example the letters of a glossary.
+	IndexBar(w io.Writer, entries []LinkEntry)
+	// GlossaryEntry emits the heading of a glossary entry providing the given anchor.
+	GlossaryEntry(w io.Writer, text, link, anchor string)
+	// Trail emits the trail of parent documents.
+	Trail(w io.Writer, entries []LinkEntry)
+	// Lines emits a block of lines separated by forced line breaks.
+	Lines(w io.Writer, lines []string)
+	// LineBreak provides the markup for a forced line break.
+	LineBreak() string
+}
 
-	"github.com/mandelsoft/mdgen/scanner"
+// HeadingAnchors is an optional interface of a Renderer, which cannot
+// emit explicit anchors. Links must use the anchors generated by the
+// markdown viewer for the headings instead.
+type HeadingAnchors interface {
+	// HeadingAnchor provides the anchor generated for a heading text.
+	// Duplicates are not made unique.
+	HeadingAnchor(text string) string
+}
+
+var (
+	lock      sync.RWMutex
+	renderers = map[string]Renderer{}
 )
 
-type Renderer = *renderer
-type renderer struct {
-	mdlink bool
+// Register registers a renderer for its flavour name.
+func Register(r Renderer) {
+	lock.Lock()
+	defer lock.Unlock()
+	renderers[r.Name()] = r
+}
+
+// Get provides the renderer for a flavour.
+func Get(name string) (Renderer, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	r := renderers[name]
+	if r == nil {
+		return nil, fmt.Errorf("unknown flavour %q", name)
+	}
+	return r, nil
 }
 
-func (r *renderer) Link(ctx scanner.ResolutionContext, link string, content func(ctx2 scanner.ResolutionContext) error) error {
-	w := ctx.Writer()
-	if r.mdlink {
-		fmt.Fprintf(w, "[")
-		err := content(ctx)
-		if err != nil {
-			return err
-		}
-		fmt.Fprintf(w, "](%s)", link)
-	} else {
-		fmt.Fprintf(w, "<a href=\"%s\">", link)
-		err := content(ctx)
-		if err != nil {
-			return err
-		}
-		fmt.Fprintf(w, "</a>")
+// Names provides the sorted list of registered flavours.
+func Names() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	var names []string
+	for n := range renderers {
+		names = append(names, n)
 	}
-	return nil
+	sort.Strings(names)
+	return names
 }
 
-var Current Renderer = &renderer{mdlink: false}
+// Default provides the default renderer generating GitHub markdown.
+func Default() Renderer {
+	return github
+}
@@ -0,0 +1,35 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package render
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var mdlinkExp = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+
+// Slug provides the anchor generated for a heading title by the
+// markdown renderer of GitHub. Links are replaced by their text,
+// all characters except letters, digits, hyphens and underscores
+// are removed and spaces are replaced by hyphens.
+// Duplicate slugs of a document are made unique by the
+// renderer by appending -1, -2 and so on.
+func Slug(title string) string {
+	title = mdlinkExp.ReplaceAllString(title, "$1")
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(title)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,26 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package render_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/mdgen/render"
+)
+
+var _ = Describe("render", func() {
+	It("generates heading slugs", func() {
+		Expect(render.Slug("Hello World")).To(Equal("hello-world"))
+		Expect(render.Slug("Statement `section`")).To(Equal("statement-section"))
+		Expect(render.Slug("1.2 Setup & [Usage](usage.md)")).To(Equal("12-setup--usage"))
+	})
+
+	It("provides the registered flavours", func() {
+		Expect(render.Names()).To(Equal([]string{render.FLAVOUR_COMMONMARK, render.FLAVOUR_GITHUB}))
+	})
+})
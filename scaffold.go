@@ -0,0 +1,89 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mandelsoft/mdgen/config"
+)
+
+var initCmd = &Command{
+	Name:  "init",
+	Args:  "[<folder>]",
+	Short: "create a new source tree",
+	Long: `
+Create a project configuration file mdgen.yaml and an initial source tree
+in the given folder (default: current folder). The sources are placed
+into the sub folder src, the markdown files are generated into the sub folder
+doc. Existing files are never overwritten.
+`,
+	Setup: func(fs *flag.FlagSet) func(args []string) error {
+		return func(args []string) error {
+			if len(args) > 1 {
+				return usageErrorf("at most a project folder expected")
+			}
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			return Scaffold(dir)
+		}
+	},
+}
+
+var scaffold = []struct {
+	path    string
+	content string
+}{
+	{config.CONFIG_FILE, `# mdgen project configuration
+source: src
+target: doc
+`},
+	{"src/README.mdg", `{{section introduction}}Introduction
+
+This document tree is generated with [mdgen](https://github.com/mandelsoft/mdgen)
+from the sources in the folder src. Use
+
+    mdgen
+
+in the project folder to regenerate the markdown files in the folder doc.
+
+{{section usage}}Usage
+
+Sections can be linked by their tags, for example to the
+{{link #introduction}}introduction{{end}}.
+
+{{endsection}}
+{{endsection}}
+`},
+}
+
+// Scaffold creates a new project in the given folder.
+func Scaffold(dir string) error {
+	for _, f := range scaffold {
+		p := filepath.Join(dir, f.path)
+		if _, err := os.Stat(p); err == nil {
+			return fmt.Errorf("%s already exists", p)
+		}
+	}
+	for _, f := range scaffold {
+		p := filepath.Join(dir, f.path)
+		err := os.MkdirAll(filepath.Dir(p), 0755)
+		if err == nil {
+			err = os.WriteFile(p, []byte(f.content), 0644)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created %s\n", p)
+	}
+	return nil
+}
@@ -97,8 +97,9 @@ type document struct {
 	NodeContainerBase
 	inventory documentInventory
 
-	template  bool
-	targetref string
+	template    bool
+	targetref   string
+	frontmatter map[string]string
 
 	refpath    string
 	references map[string]Node
@@ -132,6 +133,12 @@ func (d *document) GetTargetRefPath() string {
 	return d.targetref
 }
 
+// GetFrontMatter provides the front matter fields
+// explicitly set for the document.
+func (d *document) GetFrontMatter() map[string]string {
+	return d.frontmatter
+}
+
 func (d *document) Print(gap string) {
 	if d.template {
 		fmt.Printf("%s* template: %s\n", gap, d.Source())
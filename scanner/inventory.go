@@ -56,6 +56,7 @@ type BlockNode interface {
 
 	Name() string
 	HasParam(n string) bool
+	GetParameterNames() []string
 
 	RegisterAt(Scope) error
 
@@ -10,6 +10,8 @@ import (
 	"fmt"
 
 	"github.com/mandelsoft/mdgen/labels"
+	"github.com/mandelsoft/mdgen/logging"
+	utils2 "github.com/mandelsoft/mdgen/utils"
 )
 
 type HierarchyLabel interface {
@@ -100,7 +102,7 @@ type NumberRange interface {
 
 	SetWeight(int)
 	GetWeight() int
-	CreateLabels(rule labels.Rule)
+	CreateLabels(rule labels.Rule, log ...logging.Logger)
 }
 
 type numberrange struct {
@@ -250,7 +252,8 @@ func (n *numberrange) Actual() HierarchyLabel {
 	return l
 }
 
-func (n *numberrange) CreateLabels(rule labels.Rule) {
+func (n *numberrange) CreateLabels(rule labels.Rule, log ...logging.Logger) {
+	logger := utils2.OptionalDefaulted(logging.Discard(), log...)
 	if n.rule != nil {
 		if rule != nil {
 			rule = labels.NewComposeRule(rule.Parent(), n.sep, n.rule)
@@ -275,9 +278,9 @@ func (n *numberrange) CreateLabels(rule labels.Rule) {
 		if n.prefixlabel != nil {
 			l.label = labels.NewPrefixLabel(l.prefixlabel, n.sep, rule)
 		}
-		fmt.Printf("   %s -> %s[%d]\n", l.Id(), rule.Name(), rule.Level())
+		logger.Debugf("   %s -> %s[%d]", l.Id(), rule.Name(), rule.Level())
 		if l.nested != nil {
-			l.nested.CreateLabels(rule.Sub())
+			l.nested.CreateLabels(rule.Sub(), logger)
 		}
 		l = l.next
 	}
@@ -11,6 +11,7 @@ import (
 	"io"
 	"strings"
 
+	"github.com/mandelsoft/mdgen/diagnostics"
 	"github.com/mandelsoft/mdgen/labels"
 	"github.com/mandelsoft/mdgen/utils"
 )
@@ -154,6 +155,7 @@ type NodeContainerBase struct {
 	InventoryContainer
 	NodeSequence
 	typ string
+	end *Location
 }
 
 func NewContainerBase(typ string, d Document, location Location, parent ...InventoryContainer) NodeContainerBase {
@@ -175,6 +177,16 @@ func (c *NodeContainerBase) Type() string {
 	return c.typ
 }
 
+// EndLocation provides the location of the element finishing
+// the container, or nil, if the container is not finished.
+func (c *NodeContainerBase) EndLocation() *Location {
+	return c.end
+}
+
+func (c *NodeContainerBase) setEndLocation(l Location) {
+	c.end = &l
+}
+
 func (c *NodeContainerBase) Print(gap string) {
 	fmt.Printf("%snodes: \n", gap)
 	c.NodeSequence.Print(gap + "  ")
@@ -225,6 +237,11 @@ func (t *tokens) Get(name string) Token {
 	return t.tokens[name]
 }
 
+// Names provides the sorted names of all registered tokens.
+func (t *tokens) Names() []string {
+	return utils.StringMapKeys(t.tokens)
+}
+
 var Tokens = &tokens{map[string]Token{}}
 
 type keywords map[string]bool
@@ -294,6 +311,10 @@ func (s *BracketedStatement[N]) End(p Parser, e Element) (Element, error) {
 	if _, err := Assure[N](s.Name(), p, e); err != nil {
 		return nil, err
 	}
+	e.closes = s.Name()
+	if c, ok := p.State.Container.(interface{ setEndLocation(Location) }); ok {
+		c.setEndLocation(e.Location())
+	}
 	return Pop(p, s.useAsNode)
 }
 
@@ -387,14 +408,60 @@ func NewParser(source, refpath string, r io.Reader) Parser {
 	return p
 }
 
+// Parse parses the document. After an error the parser resynchronizes
+// at the next statement, so all syntax errors of a document are reported
+// together as diagnostics.
 func (p *parser) Parse() (Document, error) {
-	_, err := ParseUntil(p, nil, nil)
-	if err == nil {
-		if p.State.parent != nil {
-			return nil, p.Errorf("unfinished %s", p.State.Container.Type())
+	var list diagnostics.Diagnostics
+
+	e, err := p.tokenizer.NextElement()
+	for {
+		if err == nil {
+			_, err = parseElements(p, e, nil)
+			if err == nil {
+				break
+			}
+		}
+		if len(list) > 0 && list[len(list)-1].Error() == err.Error() {
+			// no progress, give up
+			break
+		}
+		list.Add(err, diagnostics.CODE_SYNTAX)
+		e, err = p.resync()
+		if e == nil && err == nil {
+			break
+		}
+	}
+	if p.State.parent != nil {
+		list.Add(p.State.Container.Errorf("unfinished %s", p.State.Container.Type()), diagnostics.CODE_SYNTAX)
+	}
+	if len(list) > 0 {
+		return nil, list
+	}
+	return p.doc, nil
+}
+
+// ParseRaw parses a document and provides its elements in source order
+// together with their raw source text (see Tokenizer.Elements). The end
+// tokens are annotated with the name of the statement they finish.
+func ParseRaw(source string, r io.Reader) ([]Element, error) {
+	p := NewParser(source, "/", r)
+	p.tokenizer.RecordRaw()
+	_, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	return p.tokenizer.Elements(), nil
+}
+
+// resync skips all elements up to the next statement.
+func (p *parser) resync() (Element, error) {
+	for {
+		e, err := p.tokenizer.NextElement()
+		if err != nil || e == nil || !e.IsText() {
+			return e, err
 		}
 	}
-	return p.doc, err
 }
 
 func (p *parser) Errorf(msg string, args ...interface{}) error {
@@ -512,6 +579,11 @@ func ParseUntil(p Parser, stop func(p Parser, e Element) bool, c NodeContainer)
 			p.State = p.State.parent
 		}()
 	}
+	return parseElements(p, e, stop)
+}
+
+func parseElements(p Parser, e Element, stop func(p Parser, e Element) bool) (Element, error) {
+	var err error
 	for e != nil {
 		if stop != nil && stop(p, e) {
 			return e, nil
@@ -564,6 +636,7 @@ func ParseSequence(p Parser, e Element) (Element, NodeSequence, error) {
 	if e.HasTags() {
 		return nil, nil, e.Errorf("no tag possible for {{end%s}}", tok)
 	}
+	e.closes = tok
 	e, err = p.tokenizer.NextElement()
 	return e, seq, err
 }
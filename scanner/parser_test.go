@@ -0,0 +1,52 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package scanner
+
+import (
+	"bytes"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/mdgen/diagnostics"
+)
+
+var _ = Describe("parser", func() {
+	It("reports all syntax errors", func() {
+		p := NewParser("doc.mdg", "/doc", bytes.NewBufferString("{{foo}}\nsome text\n{{9x}} other text\n{{bar}}\n"))
+		_, err := p.Parse()
+		Expect(err).To(HaveOccurred())
+
+		var list diagnostics.Diagnostics
+		Expect(errors.As(err, &list)).To(BeTrue())
+		Expect(list.Error()).To(Equal(`doc.mdg: line 1, column 1: unknown token "foo"
+doc.mdg: line 3, column 3: invalid character "9" in statement name
+doc.mdg: line 4, column 1: unknown token "bar"`))
+		Expect(list[0].Code).To(Equal(diagnostics.CODE_SYNTAX))
+	})
+
+	It("parses valid document", func() {
+		p := NewParser("doc.mdg", "/doc", bytes.NewBufferString("some text\n"))
+		doc, err := p.Parse()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(doc.GetNodes()).To(HaveLen(1))
+	})
+
+	It("parses front matter fields", func() {
+		p := NewParser("doc.mdg", "/doc", bytes.NewBufferString("{{frontmatter description=\"a short description\" layout=page}}some text\n"))
+		doc, err := p.Parse()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(doc.GetFrontMatter()).To(Equal(map[string]string{"description": "a short description", "layout": "page"}))
+	})
+
+	It("rejects invalid front matter fields", func() {
+		p := NewParser("doc.mdg", "/doc", bytes.NewBufferString("{{frontmatter description}}\n"))
+		_, err := p.Parse()
+		Expect(err).To(MatchError(`doc.mdg: line 1, column 1: front matter field "description" must be of the form <name>=<value>`))
+	})
+})
@@ -16,6 +16,8 @@ import (
 	"unicode/utf8"
 
 	"github.com/mandelsoft/mdgen/labels"
+	"github.com/mandelsoft/mdgen/logging"
+	"github.com/mandelsoft/mdgen/render"
 	utils2 "github.com/mandelsoft/mdgen/utils"
 )
 
@@ -222,13 +224,11 @@ func (c *LabeledNodeContextBase[N]) EmitAnchors(ctx ResolutionContext) {
 	info := ctx.GetReferencable(c.Id())
 	w := ctx.Writer()
 	fmt.Fprintf(w, "\n")
-	if anchors := info.Anchors(); len(anchors) > 0 {
-		fmt.Fprintf(w, "<a/>") // without at least two <a> github cannot render sections anymore
-		for _, a := range anchors {
-			fmt.Fprintf(w, "<a id=\"%s\"/>", a)
-		}
-		fmt.Fprintf(w, "\n")
+	var anchors []string
+	for _, a := range info.Anchors() {
+		anchors = append(anchors, ctx.OutputAnchor(a))
 	}
+	ctx.Renderer().Anchors(w, anchors)
 }
 
 func (c *LabeledNodeContextBase[N]) EmitTitle(ctx ResolutionContext) {
@@ -248,7 +248,7 @@ func (c *LabeledNodeContextBase[N]) EmitTitle(ctx ResolutionContext) {
 	if label != "" {
 		label = abbrev + label + ": "
 	}
-	fmt.Fprintf(w, " %s%s\n</br></br>\n", label, *c.Title())
+	ctx.Renderer().Caption(w, label+*c.Title())
 }
 
 type NodeContexts map[Node]NodeContext
@@ -293,14 +293,18 @@ func (c *LinkContextInfoNode[N]) GetLink() utils2.Link {
 func (c *LinkContextInfoNode[N]) Resolve(ctx ResolutionContext) error {
 	ri := ctx.LookupReferencable(c.link)
 	if ri == nil {
-		return c.Errorf("cannot resolve link %q", c.link)
+		return c.Errorf("cannot resolve link %q%s", c.link, utils2.DidYouMean(c.link.String(), ctx.ReferencableCandidates()))
 	}
 	c.RefInfo = ri
 	return nil
 }
 
 func (c *LinkContextInfoNode[N]) Link(ctx ResolutionContext) (string, error) {
-	link, err := ctx.DetermineLink(LinkFor(c, c.link.Anchor()))
+	l := c.link
+	if !l.IsExternal() {
+		l = LinkFor(c, c.link.Anchor())
+	}
+	link, err := ctx.DetermineLink(l)
 	if err != nil {
 		return link, c.Errorf("%s", err)
 	}
@@ -376,6 +380,16 @@ func LinkFor(ri RefInfo, preferred ...string) utils2.Link {
 	return utils2.NewLink(ri.GetRefPath(), anchor)
 }
 
+// ExternalTerm describes a term provided by the reference inventory
+// of another project. The link refers to the definition of the term
+// in the other project.
+type ExternalTerm struct {
+	Singular string
+	Plural   string
+	Format   string
+	Link     utils2.Link
+}
+
 type DocumentInfo interface {
 	GetRefPath() string
 	GetParentDocument() DocumentInfo
@@ -389,6 +403,11 @@ type Unscoped interface {
 
 	NextId(typ string) labels.Rule
 	RequestDocument(link utils2.Link, location Location) error
+	// EmbedDocument emits a requested document to the given writer,
+	// if structural sub documents are embedded into the generated
+	// document of their root document.
+	// It reports whether the document has been embedded.
+	EmbedDocument(link utils2.Link, w Writer) (bool, error)
 	RequestNumberRange(typ string)
 	GetNumberRange(typ string) NumberRange
 	SetNumberRangeFor(d Document, id TaggedId, typ string, nr NumberRange) HierarchyLabel
@@ -399,6 +418,9 @@ type Unscoped interface {
 	DetermineLink(l utils2.Link) (string, error)
 	GetLinkInfo(l utils2.Link) ResolvedRef
 	GetGlobalTags(typ string) []NodeContext
+	// LookupExternalTerm looks up a term in the reference inventory
+	// imported for a project prefix.
+	LookupExternalTerm(project, tag string) (*ExternalTerm, error)
 
 	GetContextNodeContext() NodeContext
 	CallStack() CallStack
@@ -406,6 +428,23 @@ type Unscoped interface {
 	Writer() Writer
 	Target() string
 	RegisterUnresolved(nctx NodeContext, err error) error
+
+	// Logger provides the logger used to report progress and problems.
+	Logger() logging.Logger
+	// Renderer provides the renderer used to emit the document elements.
+	Renderer() render.Renderer
+	// OutputAnchor maps an anchor of the actual document to the anchor
+	// used in the generated document.
+	OutputAnchor(anchor string) string
+	// LinkAnchor maps an anchor emitted into the actual generated
+	// document to the anchor used to link it.
+	LinkAnchor(anchor string) string
+	// EmitAnchors provides the anchors not yet emitted into the actual
+	// generated document and marks them as emitted.
+	EmitAnchors(anchors ...string) []string
+	// AddDependency registers a file or folder used to generate
+	// the documents, for example an included file.
+	AddDependency(path string)
 }
 
 type CallStack interface {
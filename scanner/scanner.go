@@ -11,7 +11,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"unicode/utf8"
+
+	"github.com/mandelsoft/mdgen/diagnostics"
 )
 
 type Scanner = *scanner
@@ -20,6 +23,11 @@ type scanner struct {
 	reader    *bufio.Reader
 	lookAhead string
 	location
+
+	// recording keeps the consumed input in raw,
+	// if enabled (see RecordRaw).
+	recording bool
+	raw       string
 }
 
 type Located interface {
@@ -59,12 +67,14 @@ func (l location) Column() int {
 	return l.column
 }
 
+// Errorf provides a diagnostic for the location.
 func (l location) Errorf(msg string, args ...interface{}) error {
-	return fmt.Errorf("%s: %s", l.String(), fmt.Sprintf(msg, args...))
+	return diagnostics.New(l.source, l.line, l.column, "%s", fmt.Sprintf(msg, args...))
 }
 
+// Error provides a diagnostic for the location describing the given error.
 func (l location) Error(err error) error {
-	return fmt.Errorf("%s: %s", l.String(), err.Error())
+	return diagnostics.Wrap(l.source, l.line, l.column, err)
 }
 
 func (l location) ErrorForPreviousf(msg string, args ...interface{}) error {
@@ -95,7 +105,33 @@ func NewScanner(source string, r io.Reader) Scanner {
 	}
 }
 
+// RecordRaw enables the recording of the consumed input.
+func (s *scanner) RecordRaw() {
+	s.recording = true
+}
+
+// TakeRaw provides the input consumed since the last call
+// and resets the recording.
+func (s *scanner) TakeRaw() string {
+	raw := s.raw
+	s.raw = ""
+	return raw
+}
+
+func (s *scanner) record(str string) {
+	if s.recording {
+		s.raw += str
+	}
+}
+
+func (s *scanner) unrecord(str string) {
+	if s.recording {
+		s.raw = strings.TrimSuffix(s.raw, str)
+	}
+}
+
 func (s *scanner) inc(r rune) rune {
+	s.record(string(r))
 	if r == '\n' {
 		s.line++
 		s.column = 1
@@ -123,6 +159,7 @@ func (s *scanner) SkipComment() {
 		}
 	}
 	if cnt > 0 && firstcol > 1 {
+		s.unrecord("\n")
 		s.lookAhead = "\n" + s.lookAhead
 		s.column = lastcol
 		s.line--
@@ -134,6 +171,7 @@ func (s *scanner) Next() (rune, error) {
 }
 
 func (s *scanner) Push(r rune) {
+	s.unrecord(string(r))
 	s.lookAhead = string(r) + s.lookAhead
 	s.column--
 }
@@ -167,6 +205,7 @@ func (s *scanner) Match(n string) bool {
 
 func (s *scanner) Consume(n string) bool {
 	if s.Match(n) {
+		s.record(n)
 		s.lookAhead = s.lookAhead[len(n):]
 		if n == "\n" {
 			s.line++
@@ -21,6 +21,12 @@ type LookupScope interface {
 	LookupBlock(link utils2.Link) (BlockNodeContext, Scope)
 	LookupValue(name string) *Value
 
+	// Candidates used to suggest alternatives for unresolvable references.
+	TagCandidates(typ string) []string
+	ReferencableCandidates() []string
+	BlockCandidates() []string
+	ValueCandidates() []string
+
 	RegisterTag(typ string, tag string, nctx NodeContext, explicit bool) error
 	RegisterReferencable(nctx LabeledNodeContext, tags []string, explicit bool) (RefInfo, error)
 	RegisterBlock(anchor string, nctx BlockNodeContext) error
@@ -263,6 +269,51 @@ func (s *scope) LookupValue(name string) *Value {
 	return nil
 }
 
+func (s *scope) TagCandidates(typ string) []string {
+	result := utils2.StringMapKeys(s.tagged[typ])
+	if s.static != nil {
+		result = append(result, s.static.TagCandidates(typ)...)
+	}
+	return result
+}
+
+func (s *scope) ReferencableCandidates() []string {
+	var result []string
+	for _, a := range utils2.StringMapKeys(s.referencables.localanchors) {
+		result = append(result, anchorLink(a).String())
+	}
+	if s.static != nil {
+		result = append(result, s.static.ReferencableCandidates()...)
+	}
+	return result
+}
+
+func (s *scope) BlockCandidates() []string {
+	var result []string
+	for _, a := range utils2.StringMapKeys(s.blocks) {
+		result = append(result, anchorLink(a).String())
+	}
+	if s.static != nil {
+		result = append(result, s.static.BlockCandidates()...)
+	}
+	return result
+}
+
+func (s *scope) ValueCandidates() []string {
+	result := utils2.StringMapKeys(s.values)
+	if s.static != nil {
+		result = append(result, s.static.ValueCandidates()...)
+	}
+	return result
+}
+
+func anchorLink(anchor string) utils2.Link {
+	if path.IsAbs(anchor) {
+		return utils2.NewTagLink(anchor)
+	}
+	return utils2.NewLink("", anchor)
+}
+
 func (s *scope) GetBlock(anchor string) BlockNodeContext {
 	return s.blocks[anchor]
 }
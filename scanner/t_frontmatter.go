@@ -0,0 +1,32 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package scanner
+
+import (
+	"strings"
+)
+
+func init() {
+	Tokens.Register("frontmatter", ParseFrontMatter)
+}
+
+func ParseFrontMatter(p Parser, e Element) (Element, error) {
+	if !e.HasTags() {
+		return nil, e.Errorf("front matter fields required")
+	}
+	for _, t := range e.Tags() {
+		idx := strings.Index(t, "=")
+		if idx <= 0 {
+			return nil, e.Errorf("front matter field %q must be of the form <name>=<value>", t)
+		}
+		if p.doc.frontmatter == nil {
+			p.doc.frontmatter = map[string]string{}
+		}
+		p.doc.frontmatter[t[:idx]] = t[idx+1:]
+	}
+	return p.tokenizer.NextElement()
+}
@@ -139,3 +139,21 @@ func ParseNumberRange(p Parser, e Element) (Element, error) {
 	}
 	return p.tokenizer.NextElement()
 }
+
+// ParseLabelRules parses a list of number range specifications
+// using the argument syntax of the numberrange statement. It is used
+// to describe number range defaults outside of documents. The given
+// source is used to report errors, the line number denotes the index
+// of the specification.
+func ParseLabelRules(source string, specs []string) (LabelRules, error) {
+	var buf strings.Builder
+	for _, s := range specs {
+		buf.WriteString("{{numberrange " + s + "}}\n")
+	}
+	p := NewParser(source, "/", strings.NewReader(buf.String()))
+	d, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	return d.GetLabelRules(), nil
+}
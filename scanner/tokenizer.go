@@ -24,6 +24,14 @@ type element struct {
 	tags    []string
 	text    string
 	location
+
+	// the raw source of the element, if recorded by the tokenizer.
+	prefix  string
+	raw     string
+	suffix  string
+	rawtags []string
+	// closes is the name of the statement finished by an end token.
+	closes string
 }
 
 type skipNewline map[string]bool
@@ -38,6 +46,15 @@ func (s skipNewline) Register(k string, skipnl ...bool) {
 
 var SkipNewline = skipNewline{}
 
+// Skips reports whether a newline following a token
+// at the given column is skipped.
+func (s skipNewline) Skips(token string, column int) bool {
+	if skip, set := s[token]; set {
+		return skip
+	}
+	return column <= 1
+}
+
 func NewToken(token string, tags []string, location Location, flagged bool) Element {
 	return &element{
 		flagged:  flagged,
@@ -128,6 +145,37 @@ func (e *element) Location() Location {
 	return e.location
 }
 
+// Raw provides the source text of a text element or the statement text
+// of a token. It is only available for elements provided by a tokenizer
+// recording the raw source (see RecordRaw).
+func (e *element) Raw() string {
+	return e.raw
+}
+
+// RawPrefix provides the source text consumed before the statement
+// of a token, for example comments.
+func (e *element) RawPrefix() string {
+	return e.prefix
+}
+
+// RawSuffix provides the source text consumed after the statement
+// of a token, for example a skipped newline.
+func (e *element) RawSuffix() string {
+	return e.suffix
+}
+
+// RawTags provides the source text of the tags of a token
+// including quotes and escape characters.
+func (e *element) RawTags() []string {
+	return e.rawtags
+}
+
+// Closes provides the name of the statement finished by an end token.
+// It is set by the parser.
+func (e *element) Closes() string {
+	return e.closes
+}
+
 func (e *element) String() string {
 	if e.token != "" {
 		tags := strings.Join(e.tags, "\", \"")
@@ -144,6 +192,9 @@ type Tokenizer = *tokenizer
 type tokenizer struct {
 	scanner Scanner
 	stack   []Element
+
+	recording bool
+	elements  []Element
 }
 
 func NewTokenizer(source string, r io.Reader) Tokenizer {
@@ -152,6 +203,21 @@ func NewTokenizer(source string, r io.Reader) Tokenizer {
 	}
 }
 
+// RecordRaw enables the recording of the raw source of the provided
+// elements. It must be called before the first element is requested.
+func (p *tokenizer) RecordRaw() {
+	p.recording = true
+	p.scanner.RecordRaw()
+}
+
+// Elements provides all elements provided so far in source order. If the
+// end of the input has been reached, a final text element without text
+// keeps the remaining raw source. The concatenation of the raw source of
+// all elements is the complete input.
+func (p *tokenizer) Elements() []Element {
+	return p.elements
+}
+
 func (p *tokenizer) Push(e Element) {
 	p.stack = append(p.stack, e)
 }
@@ -168,12 +234,27 @@ func (p *tokenizer) requireNext() (rune, error) {
 }
 
 func (p *tokenizer) NextElement() (Element, error) {
-
 	if len(p.stack) > 0 {
 		e := p.stack[len(p.stack)-1]
 		p.stack = p.stack[:len(p.stack)-1]
 		return e, nil
 	}
+	e, err := p.nextElement()
+	if p.recording && err == nil {
+		if e != nil {
+			p.elements = append(p.elements, e)
+		} else if rest := p.scanner.TakeRaw(); rest != "" {
+			p.elements = append(p.elements, &element{raw: rest, location: p.scanner.Location()})
+		}
+	}
+	return e, err
+}
+
+func (p *tokenizer) text(text string, loc location) Element {
+	return &element{text: text, location: loc, raw: p.scanner.TakeRaw()}
+}
+
+func (p *tokenizer) nextElement() (Element, error) {
 	var next string
 
 	text, loc, ok, err := p.parseTokenStart()
@@ -181,7 +262,7 @@ func (p *tokenizer) NextElement() (Element, error) {
 	if err != nil {
 		if errors.Is(err, io.EOF) {
 			if text != "" {
-				return &element{text: text, location: loc}, nil
+				return p.text(text, loc), nil
 			}
 			return nil, nil
 		} else {
@@ -194,19 +275,17 @@ func (p *tokenizer) NextElement() (Element, error) {
 		text += next
 		if errors.Is(err, io.EOF) {
 			if text != "" {
-				return &element{text: text, location: loc}, nil
+				return p.text(text, loc), nil
 			}
 			return nil, nil
 		}
 	}
 	if text != "" {
-		return &element{
-			text:     text,
-			location: loc,
-		}, nil
+		return p.text(text, loc), nil
 	}
 
 	flagged := false
+	prefix := p.scanner.TakeRaw()
 	p.scanner.Consume("{{")
 
 	r := ' '
@@ -244,9 +323,11 @@ func (p *tokenizer) NextElement() (Element, error) {
 	}
 
 	var tags []string
+	var rawtags []string
 	active := false
 	quoted := false
 	tag := ""
+	rawtag := ""
 	masked := false
 
 	for masked || quoted || !p.scanner.Consume("}}") {
@@ -269,37 +350,46 @@ func (p *tokenizer) NextElement() (Element, error) {
 			switch c {
 			case '\\':
 				masked = true
+				rawtag += string(c)
 				continue
 			case ' ':
 				if !quoted {
 					if active {
 						tags = append(tags, tag)
+						rawtags = append(rawtags, rawtag)
 						tag = ""
 						active = false
 					}
+					rawtag = ""
 					continue
 				}
 			case '"':
 				quoted = !quoted
+				rawtag += string(c)
 				continue
 			}
 		}
 		active = true
 		tag += string(c)
+		rawtag += string(c)
 	}
 	if active {
 		tags = append(tags, tag)
+		rawtags = append(rawtags, rawtag)
 	}
+	raw := p.scanner.TakeRaw()
 
 	if p.scanner.Match("\\\n") {
 		p.scanner.Consume("\\")
-	} else {
-		skip, set := SkipNewline[token]
-		if !set && p.scanner.Match("\n") && (loc.column <= 1) || skip {
-			p.scanner.Consume("\n")
-		}
+	} else if SkipNewline.Skips(token, loc.column) {
+		p.scanner.Consume("\n")
 	}
-	return NewToken(token, tags, loc, flagged), nil
+	e := NewToken(token, tags, loc, flagged)
+	e.prefix = prefix
+	e.raw = raw
+	e.suffix = p.scanner.TakeRaw()
+	e.rawtags = rawtags
+	return e, nil
 }
 
 func (p *tokenizer) parseTokenStart() (string, location, bool, error) {
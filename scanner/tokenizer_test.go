@@ -72,4 +72,27 @@ var _ = Describe("tokenizer", func() {
 			Expect(e.Location().line).To(Equal(2))
 		})
 	})
+
+	Context("raw source", func() {
+		It("keeps the complete input", func() {
+			src := "/# comment\n{{ *test  \"a b\"  x\\ y }}\ntext \\{{no}}\n{{end}}\\\nrest\n"
+			t := NewTokenizer("data", bytes.NewBufferString(src))
+			t.RecordRaw()
+			for e := testutils.Must(t.NextElement()); e != nil; e = testutils.Must(t.NextElement()) {
+			}
+			raw := ""
+			for _, e := range t.Elements() {
+				raw += e.RawPrefix() + e.Raw() + e.RawSuffix()
+			}
+			Expect(raw).To(Equal(src))
+
+			e := t.Elements()[0]
+			Expect(e.RawPrefix()).To(Equal("/# comment\n"))
+			Expect(e.Raw()).To(Equal("{{ *test  \"a b\"  x\\ y }}"))
+			Expect(e.RawSuffix()).To(Equal("\n"))
+			Expect(e.Tags()).To(Equal([]string{"a b", "x y"}))
+			Expect(e.RawTags()).To(Equal([]string{"\"a b\"", "x\\ y"}))
+			Expect(t.Elements()[2].RawSuffix()).To(Equal("\\"))
+		})
+	})
 })
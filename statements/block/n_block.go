@@ -157,6 +157,10 @@ func (c *blocknode) HasParam(n string) bool {
 	return ok
 }
 
+func (c *blocknode) GetParameterNames() []string {
+	return utils.StringMapKeys(c.params)
+}
+
 func (n *blocknode) Print(gap string) {
 	fmt.Printf("%sBLOCK %s[%s]\n", gap, n.Id(), n.Tag())
 	gap += "  "
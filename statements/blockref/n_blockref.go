@@ -158,7 +158,7 @@ func (n *blockrefnode) Register(ctx scanner.ResolutionContext) error {
 	}
 	b, s := ctx.LookupBlock(ref)
 	if b == nil {
-		return n.Errorf("block %q not found", n.ref)
+		return n.Errorf("block %q not found%s", n.ref, utils2.DidYouMean(ref.String(), ctx.BlockCandidates()))
 	}
 
 	tag := n.tag
@@ -78,10 +78,9 @@ func (n *node) Register(ctx scanner.ResolutionContext) error {
 
 func (n *node) Emit(ctx scanner.ResolutionContext) error {
 	if len(n.NodeSequence.GetNodes()) > 0 {
-		w := ctx.Writer()
-		fmt.Fprintf(w, "<div align=\"center\">\n\n")
-		n.NodeSequence.Emit(ctx)
-		fmt.Fprintf(w, "</div>\n")
+		return ctx.Renderer().Center(ctx.Writer(), func() error {
+			return n.NodeSequence.Emit(ctx)
+		})
 	}
 
 	return nil
@@ -102,10 +102,11 @@ func (n *escapenode) Emit(ctx scanner.ResolutionContext) error {
 	w := ctx.Writer()
 	nctx := scanner.GetNodeContext[*EscapeNodeContext](ctx, n)
 
-	txt := strings.Split(*nctx.text, "</br>")
+	br := ctx.Renderer().LineBreak()
+	txt := strings.Split(*nctx.text, br)
 	for i, t := range txt {
 		txt[i] = html.EscapeString(t)
 	}
-	fmt.Fprintf(w, "%s", strings.Join(txt, "</br>"))
+	fmt.Fprintf(w, "%s", strings.Join(txt, br))
 	return nil
 }
@@ -44,6 +44,10 @@ func (s *Statement) Start(p scanner.Parser, e scanner.Element) (scanner.Element,
 			return include.ParsePattern(p, &n.ContentHandler, e)
 		case "filter":
 			return include.ParseFilter(p, &n.ContentHandler, e)
+		case "symbol":
+			return include.ParseSymbol(p, &n.ContentHandler, e)
+		case "code":
+			return include.ParseCode(p, &n.ContentHandler, e)
 		}
 		return e, nil
 	})
@@ -109,11 +113,10 @@ func (n *executenode) Emit(ctx scanner.ResolutionContext) error {
 		return n.Errorf("cannot execute %v: %s", nctx.command, err)
 	}
 
-	data, err = n.Process(data)
+	data, line, err := n.Process(data)
 	if err != nil {
 		return n.Errorf("%v: %s", n.tags, err)
 	}
 
-	fmt.Fprintf(ctx.Writer(), "%s\n", string(data))
-	return nil
+	return n.Write(ctx.Writer(), ctx.Renderer(), data, line, "")
 }
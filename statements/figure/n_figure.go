@@ -102,12 +102,7 @@ func (n *figurenode) Emit(ctx scanner.ResolutionContext) error {
 		return n.Errorf("cannot determine target path: %s", err)
 	}
 
-	fmt.Fprintf(w, "<div align=\"center\">\n")
-	fmt.Fprintf(w, "<img src=\"%s\" alt=\"%s\" %s/>\n", path, *info.Title(), strings.Join(n.attrs, " "))
-	err = n.AnchorNode.Emit(ctx)
-	if err != nil {
-		return err
-	}
-	fmt.Fprintf(w, "</div>\n")
-	return nil
+	return ctx.Renderer().Figure(w, path, *info.Title(), n.attrs, func() error {
+		return n.AnchorNode.Emit(ctx)
+	})
 }
@@ -15,6 +15,7 @@ import (
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 
+	"github.com/mandelsoft/mdgen/render"
 	"github.com/mandelsoft/mdgen/scanner"
 	"github.com/mandelsoft/mdgen/statements/termdef"
 	"github.com/mandelsoft/mdgen/utils"
@@ -89,17 +90,17 @@ func (n *glossarynode) Print(gap string) {
 
 type Glossary map[string]map[string]*termdef.TermDefNodeContext
 
-func writerBar(w scanner.Writer, header []string, g Glossary) {
+func writerBar(ctx scanner.ResolutionContext, header []string, g Glossary) {
+	var entries []render.LinkEntry
 	for _, h := range header {
-		if g[h] == nil {
-			fmt.Fprintf(w, "%s &nbsp;", h)
-		} else {
+		e := render.LinkEntry{Text: h}
+		if g[h] != nil {
 			r, _ := utf8.DecodeRuneInString(h)
-			l := unicode.ToLower(r)
-			fmt.Fprintf(w, "[%s](%s) &nbsp;", h, string(l))
+			e.Link = string(unicode.ToLower(r))
 		}
+		entries = append(entries, e)
 	}
-	fmt.Fprintf(w, "\n\n")
+	ctx.Renderer().IndexBar(ctx.Writer(), entries)
 }
 
 func (n *glossarynode) Register(ctx scanner.ResolutionContext) error {
@@ -113,10 +114,12 @@ func (n *glossarynode) ResolveLabels(ctx scanner.ResolutionContext) error {
 		if !strings.HasPrefix(nctx.Term().Tag(), n.tag) {
 			continue
 		}
-		err := nctx.GetNodeSequence().Register(newContext(ctx, nctx.GetContext()))
+		gctx := newContext(ctx, nctx.GetContext())
+		err := nctx.GetNodeSequence().Register(gctx)
 		if err != nil {
 			return err
 		}
+		nctx.SetGlossaryContext(gctx)
 	}
 	for _, c := range tags {
 		nctx := c.(*termdef.TermDefNodeContext)
@@ -168,7 +171,7 @@ func (n *glossarynode) Emit(ctx scanner.ResolutionContext) error {
 	header := []string{"A", "B", "C", "D", "E", "F", "G", "H", "I", "J", "K", "L", "M", "N", "O", "P", "Q", "R", "S", "T", "U", "V", "W", "X", "Y", "Z"}
 outer:
 	for _, k := range utils.StringMapKeys(glossary) {
-		fmt.Printf("letter %s\n", k)
+		ctx.Logger().Debugf("%s: glossary letter %s", n.Location(), k)
 		for _, h := range header {
 			if h == k {
 				continue outer
@@ -178,14 +181,15 @@ outer:
 	}
 	w := ctx.Writer()
 
-	writerBar(w, header, glossary)
+	writerBar(ctx, header, glossary)
 
 	for _, h := range header {
 		m := glossary[h]
 		if len(m) == 0 {
 			continue
 		}
-		fmt.Fprintf(w, "## %s\n\n", h)
+		ctx.Renderer().Heading(w, 2, h)
+		fmt.Fprintf(w, "\n")
 		keys := utils.StringMapKeys(m)
 
 		for _, k := range keys {
@@ -202,7 +206,7 @@ outer:
 			if nctx.Term().IsFormatted() {
 				txt = nctx.Term().FormatSingular()
 			}
-			fmt.Fprintf(w, "### [%s](%s)<a id=\"%s\"/>\n", txt, link, "glossary/"+tag)
+			ctx.Renderer().GlossaryEntry(w, txt, link, "glossary/"+tag)
 			err = nctx.GetNodeSequence().Emit(newContext(ctx, nctx.GetContext()))
 			if err != nil {
 				return n.Errorf("term %s: %s", nctx.Term().Tag(), err)
@@ -0,0 +1,294 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package godoc
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/doc/comment"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/mandelsoft/mdgen/render"
+	"github.com/mandelsoft/mdgen/utils"
+)
+
+// PKG_SITE is used to link symbols of other packages.
+const PKG_SITE = "https://pkg.go.dev"
+
+// Package is a loaded Go package, whose documentation
+// can be rendered as markdown.
+type Package = *pkg
+
+type pkg struct {
+	fset *token.FileSet
+	doc  *doc.Package
+}
+
+// Load loads the Go package found in the given directory.
+// Test files and files excluded by build constraints are ignored.
+func Load(dir string) (Package, error) {
+	bp, err := build.Default.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	p := &pkg{fset: token.NewFileSet()}
+	var files []*ast.File
+	for _, name := range append(bp.GoFiles, bp.CgoFiles...) {
+		f, err := parser.ParseFile(p.fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	p.doc, err = doc.NewFromFiles(p.fset, files, bp.Name)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Render renders the package overview or the documentation of a
+// single function, type or method (<type>.<method>) of the package.
+// Links in doc comments refer to the rendered symbols of the package
+// or to the documentation of other packages on PKG_SITE. The optional
+// mapping function maps the emitted anchors to the anchors used for links.
+func (p *pkg) Render(w io.Writer, r render.Renderer, symbol string, mapping func(anchor string) string) error {
+	c := &context{
+		pkg:      p,
+		w:        w,
+		r:        r,
+		mapping:  mapping,
+		rendered: utils.Set[string]{},
+	}
+	if symbol == "" {
+		c.pkgdoc()
+		return nil
+	}
+	for _, f := range p.doc.Funcs {
+		if f.Name == symbol {
+			c.rendered.Add(f.Name)
+			c.function(f)
+			return nil
+		}
+	}
+	for _, t := range p.doc.Types {
+		if t.Name == symbol {
+			c.add(t)
+			c.typedoc(t)
+			return nil
+		}
+		for _, f := range t.Funcs {
+			if f.Name == symbol {
+				c.rendered.Add(f.Name)
+				c.function(f)
+				return nil
+			}
+		}
+		for _, m := range t.Methods {
+			if t.Name+"."+m.Name == symbol {
+				c.rendered.Add(symbol)
+				c.function(m)
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("exported symbol %q not found", symbol)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type context struct {
+	pkg      *pkg
+	w        io.Writer
+	r        render.Renderer
+	mapping  func(anchor string) string
+	rendered utils.Set[string]
+}
+
+// add adds a type with its functions and methods
+// to the rendered symbols.
+func (c *context) add(t *doc.Type) {
+	c.rendered.Add(t.Name)
+	for _, v := range append(t.Consts, t.Vars...) {
+		c.rendered.Add(v.Names...)
+	}
+	for _, f := range t.Funcs {
+		c.rendered.Add(f.Name)
+	}
+	for _, m := range t.Methods {
+		c.rendered.Add(t.Name + "." + m.Name)
+	}
+}
+
+func (c *context) pkgdoc() {
+	d := c.pkg.doc
+	for _, v := range append(d.Consts, d.Vars...) {
+		c.rendered.Add(v.Names...)
+	}
+	for _, f := range d.Funcs {
+		c.rendered.Add(f.Name)
+	}
+	for _, t := range d.Types {
+		c.add(t)
+	}
+
+	c.r.Anchors(c.w, []string{c.anchor("")})
+	c.text(d.Doc)
+
+	if len(d.Funcs) > 0 || len(d.Types) > 0 {
+		fmt.Fprintf(c.w, "**Index**\n\n")
+		for _, f := range d.Funcs {
+			c.entry("", f.Name, f.Decl)
+		}
+		for _, t := range d.Types {
+			c.entry("", t.Name, nil)
+			for _, f := range t.Funcs {
+				c.entry("  ", f.Name, f.Decl)
+			}
+			for _, m := range t.Methods {
+				c.entry("  ", t.Name+"."+m.Name, m.Decl)
+			}
+		}
+		fmt.Fprintf(c.w, "\n")
+	}
+
+	c.values("Constants", d.Consts)
+	c.values("Variables", d.Vars)
+	for _, f := range d.Funcs {
+		c.function(f)
+	}
+	for _, t := range d.Types {
+		c.typedoc(t)
+	}
+}
+
+// entry emits an index entry linking a rendered symbol.
+func (c *context) entry(indent string, name string, decl *ast.FuncDecl) {
+	text := "type " + name
+	if decl != nil {
+		text = c.source(decl)
+	}
+	fmt.Fprintf(c.w, "%s- ", indent)
+	c.r.Link(c.w, c.ref(name), func() error {
+		fmt.Fprintf(c.w, "`%s`", text)
+		return nil
+	})
+	fmt.Fprintf(c.w, "\n")
+}
+
+func (c *context) values(title string, values []*doc.Value) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Fprintf(c.w, "**%s**\n\n", title)
+	for _, v := range values {
+		c.value(v)
+	}
+}
+
+func (c *context) value(v *doc.Value) {
+	var anchors []string
+	for _, n := range v.Names {
+		anchors = append(anchors, c.anchor(n))
+	}
+	c.r.Anchors(c.w, anchors)
+	c.code(v.Decl)
+	c.text(v.Doc)
+}
+
+func (c *context) typedoc(t *doc.Type) {
+	c.r.Anchors(c.w, []string{c.anchor(t.Name)})
+	fmt.Fprintf(c.w, "**type %s**\n\n", t.Name)
+	c.code(t.Decl)
+	c.text(t.Doc)
+	for _, v := range append(t.Consts, t.Vars...) {
+		c.value(v)
+	}
+	for _, f := range t.Funcs {
+		c.function(f)
+	}
+	for _, m := range t.Methods {
+		c.function(m)
+	}
+}
+
+func (c *context) function(f *doc.Func) {
+	name := f.Name
+	title := "func " + f.Name
+	if f.Recv != "" {
+		name = strings.TrimPrefix(f.Recv, "*") + "." + f.Name
+		title = fmt.Sprintf("func (%s) %s", f.Recv, f.Name)
+	}
+	c.r.Anchors(c.w, []string{c.anchor(name)})
+	fmt.Fprintf(c.w, "**%s**\n\n", title)
+	c.code(f.Decl)
+	c.text(f.Doc)
+}
+
+// code emits the source of a declaration as fenced code block.
+func (c *context) code(decl ast.Node) {
+	fmt.Fprintf(c.w, "```go\n%s\n```\n\n", c.source(decl))
+}
+
+// source provides the formatted source of a declaration.
+func (c *context) source(decl ast.Node) string {
+	var buf bytes.Buffer
+	err := (&printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}).Fprint(&buf, c.pkg.fset, decl)
+	if err != nil {
+		return fmt.Sprintf("/* %s */", err)
+	}
+	return buf.String()
+}
+
+// text emits a doc comment as markdown.
+func (c *context) text(text string) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	p := c.pkg.doc.Printer()
+	p.DocLinkURL = c.link
+	fmt.Fprintf(c.w, "%s\n", p.Markdown(c.pkg.doc.Parser().Parse(text)))
+}
+
+// link provides the URL for a link found in a doc comment.
+func (c *context) link(link *comment.DocLink) string {
+	if link.ImportPath != "" {
+		return link.DefaultURL(PKG_SITE)
+	}
+	name := link.Name
+	if link.Recv != "" {
+		name = link.Recv + "." + name
+	}
+	if !c.rendered.Has(name) {
+		return ""
+	}
+	return c.ref(name)
+}
+
+// ref provides the link for a rendered symbol of the package.
+func (c *context) ref(name string) string {
+	if c.mapping == nil {
+		return "#" + c.anchor(name)
+	}
+	return "#" + c.mapping(c.anchor(name))
+}
+
+// anchor provides the anchor for a symbol of the package.
+func (c *context) anchor(name string) string {
+	if name == "" {
+		return "godoc-" + c.pkg.doc.Name
+	}
+	return "godoc-" + c.pkg.doc.Name + "." + name
+}
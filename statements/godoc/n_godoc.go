@@ -0,0 +1,127 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package godoc
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mandelsoft/filepath/pkg/filepath"
+
+	"github.com/mandelsoft/mdgen/render"
+	"github.com/mandelsoft/mdgen/scanner"
+)
+
+func init() {
+	scanner.Tokens.RegisterStatement(NewStatement())
+}
+
+type Statement struct {
+	scanner.StatementBase
+}
+
+func NewStatement() scanner.Statement {
+	return &Statement{scanner.NewStatementBase("godoc")}
+}
+
+func (s *Statement) Start(p scanner.Parser, e scanner.Element) (scanner.Element, error) {
+	tags := e.Tags()
+	if len(tags) == 0 || len(tags) > 2 {
+		return nil, e.Errorf("package path and optional symbol name expected")
+	}
+	symbol := ""
+	if len(tags) > 1 {
+		symbol = tags[1]
+	}
+	p.State.Container.AddNode(NewGoDocNode(p.Document(), e.Location(), tags[0], symbol))
+	return p.NextElement()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type GoDocNodeContext struct {
+	scanner.NodeContextBase[*godocnode]
+	dir string
+}
+
+func NewGoDocNodeContext(n *godocnode, ctx scanner.ResolutionContext, dir string) *GoDocNodeContext {
+	return &GoDocNodeContext{
+		NodeContextBase: scanner.NewNodeContextBase(n, ctx),
+		dir:             dir,
+	}
+}
+
+type GoDocNode = *godocnode
+
+type godocnode struct {
+	scanner.NodeBase
+	path   string
+	symbol string
+}
+
+func NewGoDocNode(d scanner.Document, location scanner.Location, path, symbol string) GoDocNode {
+	return &godocnode{
+		NodeBase: scanner.NewNodeBase(d, location),
+		path:     path,
+		symbol:   symbol,
+	}
+}
+
+func (n *godocnode) Print(gap string) {
+	if n.symbol != "" {
+		fmt.Printf("%sGODOC %s %s\n", gap, n.path, n.symbol)
+	} else {
+		fmt.Printf("%sGODOC %s\n", gap, n.path)
+	}
+}
+
+func (n *godocnode) Register(ctx scanner.ResolutionContext) error {
+	dir := n.path
+	if !filepath.IsAbs(n.path) {
+		dir = filepath.Join(filepath.Dir(n.Source()), n.path)
+	}
+	ctx.AddDependency(dir)
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return n.Errorf("cannot read go package %q: %s", n.path, err)
+	}
+	if !fi.IsDir() {
+		return n.Errorf("go package %q is no directory", n.path)
+	}
+	nctx := NewGoDocNodeContext(n, ctx, dir)
+	ctx.SetNodeContext(n, nctx)
+	return nil
+}
+
+func (n *godocnode) Emit(ctx scanner.ResolutionContext) error {
+	nctx := scanner.GetNodeContext[*GoDocNodeContext](ctx, n)
+
+	pkg, err := Load(nctx.dir)
+	if err != nil {
+		return n.Errorf("go package %q: %s", n.path, err)
+	}
+	err = pkg.Render(ctx.Writer(), &anchorFilter{ctx.Renderer(), ctx}, n.symbol, ctx.LinkAnchor)
+	if err != nil {
+		return n.Errorf("go package %q: %s", n.path, err)
+	}
+	return nil
+}
+
+// anchorFilter suppresses the anchors already emitted into the
+// generated document, for example by another godoc statement
+// for the same package.
+type anchorFilter struct {
+	render.Renderer
+	ctx scanner.ResolutionContext
+}
+
+func (r *anchorFilter) Anchors(w io.Writer, anchors []string) {
+	if anchors = r.ctx.EmitAnchors(anchors...); len(anchors) > 0 {
+		r.Renderer.Anchors(w, anchors)
+	}
+}
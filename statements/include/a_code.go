@@ -0,0 +1,174 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package include
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mandelsoft/filepath/pkg/filepath"
+
+	"github.com/mandelsoft/mdgen/render"
+	"github.com/mandelsoft/mdgen/scanner"
+)
+
+func init() {
+	scanner.Keywords.Register("code", true)
+
+}
+
+func ParseCode(p scanner.Parser, n *ContentHandler, e scanner.Element) (scanner.Element, error) {
+	if n.code != nil {
+		return nil, e.Errorf("code format already set")
+	}
+
+	code := &CodeFormat{}
+	for i, t := range e.Tags() {
+		if t == "lines" {
+			code.numbered = true
+			continue
+		}
+		off := strings.Index(t, "=")
+		if off < 0 {
+			if i > 0 {
+				return nil, e.Errorf("argument %d [%s] requires assignment", i+1, t)
+			}
+			code.language = t
+			continue
+		}
+		v := t[off+1:]
+		switch t[:off] {
+		case "lines":
+			start, err := strconv.ParseInt(v, 10, 32)
+			if err != nil || start < 1 {
+				return nil, e.Errorf("invalid start line %q: positive number expected", v)
+			}
+			code.numbered = true
+			code.lines = int(start)
+		case "caption":
+			code.caption = v
+		default:
+			return nil, e.Errorf("argument %d [%s]: unknown code attribute %q", i+1, t, t[:off])
+		}
+	}
+	n.code = code
+	return p.NextElement()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// languages maps file extensions or file names
+// to the language names used for fenced code blocks.
+var languages = map[string]string{
+	".go":           "go",
+	".mod":          "go",
+	".sh":           "bash",
+	".bash":         "bash",
+	".py":           "python",
+	".js":           "javascript",
+	".ts":           "typescript",
+	".java":         "java",
+	".c":            "c",
+	".h":            "c",
+	".cpp":          "cpp",
+	".rs":           "rust",
+	".rb":           "ruby",
+	".yaml":         "yaml",
+	".yml":          "yaml",
+	".json":         "json",
+	".toml":         "toml",
+	".xml":          "xml",
+	".html":         "html",
+	".css":          "css",
+	".sql":          "sql",
+	".proto":        "protobuf",
+	".md":           "markdown",
+	".mdg":          "markdown",
+	".txt":          "text",
+	"Makefile":      "makefile",
+	"Dockerfile":    "dockerfile",
+	"Containerfile": "dockerfile",
+}
+
+// Language determines the language of a fenced code block used
+// for the content of a file from the file name or its extension.
+// Unknown extensions are used as language name.
+func Language(path string) string {
+	name := filepath.Base(path)
+	if l, ok := languages[name]; ok {
+		return l
+	}
+	ext := filepath.Ext(name)
+	if l, ok := languages[ext]; ok {
+		return l
+	}
+	return strings.TrimPrefix(ext, ".")
+}
+
+// CodeFormat describes the emission of content as fenced code block.
+type CodeFormat struct {
+	language string
+	numbered bool
+	lines    int
+	caption  string
+}
+
+// Emit emits the content as fenced code block. The given language
+// is used, if no explicit language is configured. Numbered lines
+// start with the given line number, if no explicit start line is
+// configured. The fence is lengthened if the content contains a
+// sequence of backticks.
+func (c *CodeFormat) Emit(w io.Writer, r render.Renderer, data []byte, line int, language string) error {
+	if c.language != "" {
+		language = c.language
+	}
+	if c.lines > 0 {
+		line = c.lines
+	}
+	content := strings.TrimRight(string(data), "\n")
+	if c.numbered {
+		lines := strings.Split(content, "\n")
+		width := len(strconv.Itoa(line + len(lines) - 1))
+		for i, l := range lines {
+			lines[i] = fmt.Sprintf("%*d  %s", width, line+i, l)
+		}
+		content = strings.Join(lines, "\n")
+	}
+	fence := strings.Repeat("`", fenceLength(content))
+
+	block := func() error {
+		fmt.Fprintf(w, "%s%s\n%s\n%s\n", fence, language, content, fence)
+		return nil
+	}
+	if c.caption == "" {
+		return block()
+	}
+	return r.Labeled(w, false, block, func() error {
+		r.Caption(w, c.caption)
+		return nil
+	})
+}
+
+// fenceLength provides the length of a backtick fence
+// exceeding all backtick sequences of the content.
+func fenceLength(content string) int {
+	max := 2
+	n := 0
+	for _, c := range content {
+		if c == '`' {
+			n++
+			if n > max {
+				max = n
+			}
+		} else {
+			n = 0
+		}
+	}
+	return max + 1
+}
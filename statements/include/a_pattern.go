@@ -7,6 +7,7 @@
 package include
 
 import (
+	"bytes"
 	"fmt"
 	"regexp"
 
@@ -43,17 +44,17 @@ type PatternExtractor struct {
 	pattern string
 }
 
-func (i *PatternExtractor) Extract(data []byte) ([]byte, error) {
+func (i *PatternExtractor) Extract(data []byte) ([]byte, int, error) {
 	_, start, err := i.match(data, "begin")
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	end, _, err := i.match(data, "end")
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return data[start:end], nil
+	return data[start:end], bytes.Count(data[:start], []byte("\n")) + 1, nil
 }
 
 func (i *PatternExtractor) match(data []byte, key string) (int, int, error) {
@@ -64,21 +64,21 @@ type NumExtractor struct {
 	start, end int
 }
 
-func (i *NumExtractor) Extract(data []byte) ([]byte, error) {
+func (i *NumExtractor) Extract(data []byte) ([]byte, int, error) {
 	lines := strings.Split(string(data), "\n")
 	start := 0
 	if i.start > 0 {
 		start = i.start - 1
 	}
 	if start >= len(lines) {
-		return nil, fmt.Errorf("start line %d after end of data (%d lines)", start, len(lines))
+		return nil, 0, fmt.Errorf("start line %d after end of data (%d lines)", start, len(lines))
 	}
 	end := len(lines)
 	if i.end > 0 {
 		end = i.end
 	}
 	if end > len(lines) {
-		return nil, fmt.Errorf("end line %d after end of file (%d lines", end, len(lines))
+		return nil, 0, fmt.Errorf("end line %d after end of file (%d lines", end, len(lines))
 	}
-	return []byte(strings.Join(lines[start:end], "\n")), nil
+	return []byte(strings.Join(lines[start:end], "\n")), start + 1, nil
 }
@@ -0,0 +1,182 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package include
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+
+	"github.com/mandelsoft/mdgen/scanner"
+)
+
+func init() {
+	scanner.Keywords.Register("symbol", true)
+
+}
+
+var extractExpSym = regexp.MustCompile(`^[\pL_][\pL\pN_]*(\.[\pL_][\pL\pN_]*)?$`)
+
+func ParseSymbol(p scanner.Parser, n *ContentHandler, e scanner.Element) (scanner.Element, error) {
+	tags := e.Tags()
+	if len(tags) == 0 || len(tags) > 2 {
+		return nil, e.Errorf("symbol name and optional doc flag expected")
+	}
+	if n.extract != nil {
+		return nil, e.Errorf("range specification already set")
+	}
+
+	if !extractExpSym.MatchString(tags[0]) {
+		return nil, e.Errorf("invalid symbol name %q: expected <name> or <type>.<method>", tags[0])
+	}
+	doc := false
+	if len(tags) > 1 {
+		if tags[1] != "doc" {
+			return nil, e.Errorf("invalid symbol option %q: only doc possible", tags[1])
+		}
+		doc = true
+	}
+	n.extract = &SymbolExtractor{tags[0], doc}
+	return p.NextElement()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SymbolExtractor extracts the source of a top-level declaration
+// of a Go source file. Functions and types are selected by their
+// name, methods by <type>.<method>. For constants and variables the
+// complete declaration block is extracted.
+type SymbolExtractor struct {
+	symbol string
+	doc    bool
+}
+
+func (i *SymbolExtractor) Extract(data []byte) ([]byte, int, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", data, parser.ParseComments)
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot parse go source: %w", err)
+	}
+
+	var found []*declaration
+	for _, d := range f.Decls {
+		switch decl := d.(type) {
+		case *ast.FuncDecl:
+			if funcName(decl) == i.symbol {
+				found = append(found, &declaration{decl, decl.Doc, false})
+			}
+		case *ast.GenDecl:
+			for _, s := range decl.Specs {
+				if !declares(s, i.symbol) {
+					continue
+				}
+				if ts, ok := s.(*ast.TypeSpec); ok && decl.Lparen.IsValid() {
+					found = append(found, &declaration{ts, ts.Doc, true})
+				} else {
+					found = append(found, &declaration{decl, decl.Doc, false})
+				}
+				break
+			}
+		}
+	}
+	if len(found) == 0 {
+		return nil, 0, fmt.Errorf("symbol %q not found", i.symbol)
+	}
+	if len(found) != 1 {
+		return nil, 0, fmt.Errorf("symbol %q is not unique", i.symbol)
+	}
+	return found[0].source(fset, data, i.doc), found[0].line(fset, i.doc), nil
+}
+
+// declaration is a found declaration. Type specs of grouped type
+// declarations are extracted as separate type declarations.
+type declaration struct {
+	node    ast.Node
+	doc     *ast.CommentGroup
+	grouped bool
+}
+
+// line provides the number of the first line of the extracted source.
+func (d *declaration) line(fset *token.FileSet, doc bool) int {
+	if doc && d.doc != nil {
+		return fset.Position(d.doc.Pos()).Line
+	}
+	return fset.Position(d.node.Pos()).Line
+}
+
+func (d *declaration) source(fset *token.FileSet, data []byte, doc bool) []byte {
+	var result []byte
+	if doc && d.doc != nil {
+		start := fset.Position(d.doc.Pos()).Offset
+		if d.grouped {
+			end := fset.Position(d.doc.End()).Offset
+			result = append(result, data[start:end]...)
+			result = append(result, '\n')
+		} else {
+			end := fset.Position(d.node.Pos()).Offset
+			result = append(result, data[start:end]...)
+		}
+	}
+	start := fset.Position(d.node.Pos()).Offset
+	end := fset.Position(d.node.End()).Offset
+	if !d.grouped {
+		return append(result, data[start:end]...)
+	}
+
+	// remove the indentation of the grouped declaration
+	sol := bytes.LastIndexByte(data[:start], '\n') + 1
+	indent := data[sol:start]
+	lines := bytes.Split(data[start:end], []byte("\n"))
+	for i := 1; i < len(lines); i++ {
+		lines[i] = bytes.TrimPrefix(lines[i], indent)
+	}
+	result = append(result, "type "...)
+	return append(result, bytes.Join(lines, []byte("\n"))...)
+}
+
+// funcName provides the symbol name of a function (<name>)
+// or a method (<type>.<method>).
+func funcName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+	typ := decl.Recv.List[0].Type
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+			continue
+		case *ast.IndexExpr:
+			typ = t.X
+			continue
+		case *ast.IndexListExpr:
+			typ = t.X
+			continue
+		case *ast.Ident:
+			return t.Name + "." + decl.Name.Name
+		}
+		return decl.Name.Name
+	}
+}
+
+// declares checks whether a spec declares the given name.
+func declares(s ast.Spec, name string) bool {
+	switch spec := s.(type) {
+	case *ast.TypeSpec:
+		return spec.Name.Name == name
+	case *ast.ValueSpec:
+		for _, n := range spec.Names {
+			if n.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
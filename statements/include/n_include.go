@@ -8,10 +8,12 @@ package include
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/mandelsoft/filepath/pkg/filepath"
 
+	"github.com/mandelsoft/mdgen/render"
 	"github.com/mandelsoft/mdgen/scanner"
 )
 
@@ -43,6 +45,10 @@ func (s *Statement) Start(p scanner.Parser, e scanner.Element) (scanner.Element,
 			return ParsePattern(p, &n.ContentHandler, e)
 		case "filter":
 			return ParseFilter(p, &n.ContentHandler, e)
+		case "symbol":
+			return ParseSymbol(p, &n.ContentHandler, e)
+		case "code":
+			return ParseCode(p, &n.ContentHandler, e)
 		}
 		return e, nil
 	})
@@ -74,28 +80,46 @@ type includenode struct {
 type ContentHandler struct {
 	extract Extractor
 	filter  Filter
+	code    *CodeFormat
 }
 
-func (n *ContentHandler) Process(data []byte) ([]byte, error) {
+// Process extracts and filters the content. Additionally, the number
+// of the first extracted line of the original content is provided.
+func (n *ContentHandler) Process(data []byte) ([]byte, int, error) {
 	var err error
 
+	line := 1
 	if n.extract != nil {
-		data, err = n.extract.Extract(data)
+		data, line, err = n.extract.Extract(data)
 		if err != nil {
-			return nil, fmt.Errorf("cannot extract data: %w", err)
+			return nil, 0, fmt.Errorf("cannot extract data: %w", err)
 		}
 	}
 	if n.filter != nil {
 		data, err = n.filter.Filter(data)
 		if err != nil {
-			return nil, fmt.Errorf("cannot filter data: %w", err)
+			return nil, 0, fmt.Errorf("cannot filter data: %w", err)
 		}
 	}
-	return data, nil
+	return data, line, nil
 }
 
+// Write writes the processed content, optionally as fenced code block
+// using the given language if no explicit language is configured.
+// line is the number of the first line of the content used for
+// numbered lines.
+func (n *ContentHandler) Write(w io.Writer, r render.Renderer, data []byte, line int, language string) error {
+	if n.code == nil {
+		fmt.Fprintf(w, "%s\n", string(data))
+		return nil
+	}
+	return n.code.Emit(w, r, data, line, language)
+}
+
+// Extractor extracts a part of the content. It provides the
+// extracted data and the number of its first line in the content.
 type Extractor interface {
-	Extract(data []byte) ([]byte, error)
+	Extract(data []byte) ([]byte, int, error)
 }
 
 type Filter interface {
@@ -118,6 +142,7 @@ func (n *includenode) Register(ctx scanner.ResolutionContext) error {
 	if !filepath.IsAbs(n.tag) {
 		file = filepath.Join(filepath.Dir(n.Source()), n.tag)
 	}
+	ctx.AddDependency(file)
 	_, err := os.ReadFile(file)
 	if err != nil {
 		return n.Errorf("cannot read include file %q: %s", n.tag, err)
@@ -134,11 +159,10 @@ func (n *includenode) Emit(ctx scanner.ResolutionContext) error {
 		return n.Errorf("cannot read include file %q: %s", n.tag, err)
 	}
 
-	data, err = n.Process(data)
+	data, line, err := n.Process(data)
 	if err != nil {
 		return n.Errorf("%q: %s", n.tag, err)
 	}
 
-	fmt.Fprintf(ctx.Writer(), "%s\n", string(data))
-	return nil
+	return n.Write(ctx.Writer(), ctx.Renderer(), data, line, Language(n.tag))
 }
@@ -15,6 +15,7 @@ import (
 	_ "github.com/mandelsoft/mdgen/statements/execute"
 	_ "github.com/mandelsoft/mdgen/statements/figure"
 	_ "github.com/mandelsoft/mdgen/statements/glossary"
+	_ "github.com/mandelsoft/mdgen/statements/godoc"
 	_ "github.com/mandelsoft/mdgen/statements/include"
 	_ "github.com/mandelsoft/mdgen/statements/label"
 	_ "github.com/mandelsoft/mdgen/statements/labeled"
@@ -154,19 +154,10 @@ func (n *labelednode) Emit(ctx scanner.ResolutionContext) error {
 	nctx := scanner.GetNodeContext[*LabeledNodeContext](ctx, n)
 
 	nctx.EmitAnchors(ctx)
-	if n.box {
-		fmt.Fprintf(w, "<div align=\"center\"><table><tr><td>\n\n")
-	}
-	err := n.NodeSequence.Emit(ctx)
-	if err != nil {
-		return err
-	}
-	if n.box {
-		fmt.Fprintf(w, "</td></tr></table>\n")
-	} else {
-		fmt.Fprintf(w, "<div align=\"center\">\n")
-	}
-	nctx.EmitTitle(ctx)
-	fmt.Fprintf(w, "</div>\n")
-	return nil
+	return ctx.Renderer().Labeled(w, n.box, func() error {
+		return n.NodeSequence.Emit(ctx)
+	}, func() error {
+		nctx.EmitTitle(ctx)
+		return nil
+	})
 }
@@ -9,7 +9,6 @@ package link
 import (
 	"fmt"
 
-	"github.com/mandelsoft/mdgen/render"
 	"github.com/mandelsoft/mdgen/scanner"
 	"github.com/mandelsoft/mdgen/utils"
 )
@@ -104,6 +103,6 @@ func (n *linknode) Emit(ctx scanner.ResolutionContext) error {
 	content := func(ctx scanner.ResolutionContext) error {
 		return n.NodeSequence.Emit(ctx)
 	}
-	render.Current.Link(ctx, link, content)
+	ctx.Renderer().Link(ctx.Writer(), link, func() error { return content(ctx) })
 	return nil
 }
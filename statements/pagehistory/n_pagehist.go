@@ -9,6 +9,7 @@ package include
 import (
 	"fmt"
 
+	"github.com/mandelsoft/mdgen/render"
 	"github.com/mandelsoft/mdgen/scanner"
 	"github.com/mandelsoft/mdgen/utils"
 )
@@ -57,13 +58,8 @@ func (n *node) Emit(ctx scanner.ResolutionContext) error {
 	if p == nil {
 		return nil
 	}
-	w := ctx.Writer()
-	first := true
+	var trail []render.LinkEntry
 	for p != nil {
-		if !first {
-			fmt.Fprintf(w, "&nbsp;&#10230;&nbsp;")
-		}
-		first = false
 		l := utils.NewLink(p.GetRefPath(), "")
 		link, err := ctx.DetermineLink(l)
 		if err != nil {
@@ -71,9 +67,9 @@ func (n *node) Emit(ctx scanner.ResolutionContext) error {
 		}
 
 		info := ctx.GetLinkInfo(l)
-		fmt.Fprintf(w, "[%s](%s)", *info.Title(), link)
+		trail = append(trail, render.LinkEntry{Text: *info.Title(), Link: link})
 		p = p.GetParentDocument()
 	}
-	fmt.Fprintf(w, "\n\n---\n\n")
+	ctx.Renderer().Trail(ctx.Writer(), trail)
 	return nil
 }
@@ -12,7 +12,6 @@ import (
 	"unicode"
 	"unicode/utf8"
 
-	"github.com/mandelsoft/mdgen/render"
 	"github.com/mandelsoft/mdgen/scanner"
 	"github.com/mandelsoft/mdgen/utils"
 )
@@ -137,5 +136,5 @@ func (n *Refnode) Emit(ctx scanner.ResolutionContext) error {
 		return nil
 	}
 
-	return render.Current.Link(ctx, link, label)
+	return ctx.Renderer().Link(ctx.Writer(), link, func() error { return label(ctx) })
 }
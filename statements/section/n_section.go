@@ -7,8 +7,6 @@
 package section
 
 import (
-	"fmt"
-
 	"github.com/mandelsoft/mdgen/scanner"
 	"github.com/mandelsoft/mdgen/statements/sectionref"
 	"github.com/mandelsoft/mdgen/statements/subrange"
@@ -62,17 +60,13 @@ func (n *node) Emit(ctx scanner.ResolutionContext) error {
 
 	lvl := info.Label().Level()
 	if lvl < 0 {
-		fmt.Printf("WARN: invalid level %d for %s\n", lvl, info.Label().Id())
+		ctx.Logger().Warnf("%s: invalid level %d for %s", n.Location(), lvl, info.Label().Id())
 		lvl = 1
 	}
-	for lvl >= 0 {
-		fmt.Fprintf(w, "#")
-		lvl--
-	}
 	label := info.Label().Name()
 	if label != "" {
 		label += " "
 	}
-	fmt.Fprintf(w, " %s%s\n", label, *nctx.Title())
+	ctx.Renderer().Heading(w, lvl+1, label+*nctx.Title())
 	return n.GetContent().Emit(nctx.GetContext())
 }
@@ -9,7 +9,6 @@ package sectionref
 import (
 	"fmt"
 
-	"github.com/mandelsoft/mdgen/render"
 	"github.com/mandelsoft/mdgen/scanner"
 	"github.com/mandelsoft/mdgen/utils"
 )
@@ -142,7 +141,8 @@ func (n *sectionrefnode) Emit(ctx scanner.ResolutionContext) error {
 		content := func(ctx scanner.ResolutionContext) error {
 			return n.NodeSequence.Emit(ctx)
 		}
-		render.Current.Link(ctx, link, content)
+		ctx.Renderer().Link(ctx.Writer(), link, func() error { return content(ctx) })
 	}
-	return nil
+	_, err = ctx.EmbedDocument(nctx.GetLink(), ctx.Writer())
+	return err
 }
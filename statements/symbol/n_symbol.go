@@ -58,10 +58,18 @@ func (n *node) Print(gap string) {
 }
 
 func (n *node) Emit(ctx scanner.ResolutionContext) error {
-	fmt.Fprintf(ctx.Writer(), n.symbol)
+	fmt.Fprintf(ctx.Writer(), "%s", n.text(ctx))
 	return nil
 }
 func (n *node) EvaluateStatic(ctx scanner.ResolutionContext) error {
-	fmt.Fprintf(ctx.Writer(), n.symbol)
+	fmt.Fprintf(ctx.Writer(), "%s", n.text(ctx))
 	return nil
 }
+
+// text provides the flavour specific markup for a line break.
+func (n *node) text(ctx scanner.ResolutionContext) string {
+	if n.name == "br" {
+		return ctx.Renderer().LineBreak()
+	}
+	return n.symbol
+}
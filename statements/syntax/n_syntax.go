@@ -84,16 +84,16 @@ func (n *node) Emit(ctx scanner.ResolutionContext) error {
 		buf := scanner.NewBufferContext(ctx)
 		n.NodeSequence.Emit(buf)
 		txt := strings.TrimSpace(buf.String())
-		fmt.Fprintf(w, "<div>\n\n")
+		var lines []string
 		for _, t := range strings.Split(txt, "\n") {
 			t = strings.TrimSpace(t)
 			t, err := utils.Syntax(t)
 			if err != nil {
 				return n.Error(err)
 			}
-			fmt.Fprintf(w, "%s</br>\n", t)
+			lines = append(lines, t)
 		}
-		fmt.Fprintf(w, "</div>\n")
+		ctx.Renderer().Lines(w, lines)
 	}
 	return nil
 }
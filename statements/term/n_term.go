@@ -10,7 +10,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/mandelsoft/mdgen/render"
 	"github.com/mandelsoft/mdgen/scanner"
 	"github.com/mandelsoft/mdgen/statements/glossary"
 	"github.com/mandelsoft/mdgen/statements/termdef"
@@ -125,8 +124,8 @@ func (n *termnode) Emit(ctx scanner.ResolutionContext) error {
 		return err
 	}
 
-	if ctx.Info(glossary.InfoKey) == true {
-		link = "#glossary/" + nctx.term.Tag()
+	if ctx.Info(glossary.InfoKey) == true && nctx.term.Project() == "" {
+		link = "#" + ctx.LinkAnchor("glossary/"+nctx.term.Tag())
 	}
 
 	content := func(ctx scanner.ResolutionContext) error {
@@ -145,7 +144,7 @@ func (n *termnode) Emit(ctx scanner.ResolutionContext) error {
 	}
 
 	if n.link {
-		render.Current.Link(ctx, link, content)
+		ctx.Renderer().Link(ctx.Writer(), link, func() error { return content(ctx) })
 	} else {
 		content(ctx)
 	}
@@ -46,6 +46,9 @@ func (s *Statement) Start(p scanner.Parser, e scanner.Element) (scanner.Element,
 	if skip {
 		tag = tag[1:]
 	}
+	if project, _ := utils2.SplitProjectPrefix(tag); project != "" {
+		return nil, e.Errorf("term definition %q must not use a project prefix", tag)
+	}
 	n := NewTermDefNode(p, e.Location(), tag, skip)
 
 	stop := func(p scanner.Parser, e scanner.Element) bool {
@@ -77,6 +80,7 @@ type TermDefNodeContext struct {
 	singular     string
 	plural       string
 	referencable *subrange.NodeContext
+	glossary     scanner.ResolutionContext
 }
 
 func NewTermDefNodeContext(n *TermdefNode, ctx scanner.ResolutionContext, rctx *subrange.NodeContext) (*TermDefNodeContext, error) {
@@ -114,6 +118,19 @@ func (c *TermDefNodeContext) Term() *Term {
 	return c.term
 }
 
+// SetGlossaryContext sets the context used by a glossary
+// to resolve the description of the term.
+func (c *TermDefNodeContext) SetGlossaryContext(ctx scanner.ResolutionContext) {
+	c.glossary = ctx
+}
+
+// GetGlossaryContext provides the context used to resolve the
+// description of the term. It is nil, if the term is not
+// described by a glossary.
+func (c *TermDefNodeContext) GetGlossaryContext() scanner.ResolutionContext {
+	return c.glossary
+}
+
 type TermDefNode = *TermdefNode
 
 type TermdefNode struct {
@@ -17,12 +17,16 @@ import (
 )
 
 type TermRef struct {
-	tag    string
-	plural bool
-	upper  bool
+	project string
+	tag     string
+	plural  bool
+	upper   bool
 }
 
 func (t TermRef) Evaluate(ctx scanner.ResolutionContext) (TermRef, bool, error) {
+	if t.project != "" {
+		return t, true, nil
+	}
 	tag, explicit, err := scanner.EvaluateTag(ctx, t.tag)
 	t.tag = tag
 	return t, explicit, err
@@ -32,6 +36,12 @@ func (t *TermRef) Tag() string {
 	return t.tag
 }
 
+// Project provides the prefix of the project providing
+// an external term. It is empty for terms of the actual tree.
+func (t *TermRef) Project() string {
+	return t.project
+}
+
 func (t *TermRef) Mode() string {
 	mode := "singular"
 	if t.plural {
@@ -51,11 +61,12 @@ func NewTerm(ref TermRef) *Term {
 
 type Term struct {
 	resolved *TermDefNodeContext
+	external *scanner.ExternalTerm
 	TermRef
 }
 
 func (t *Term) IsFormatted() bool {
-	return t.resolved.format != ""
+	return t.Markup() != ""
 }
 
 func (t *Term) Tag() string {
@@ -63,27 +74,42 @@ func (t *Term) Tag() string {
 }
 
 func (t *Term) Singular() string {
+	if t.external != nil {
+		return t.external.Singular
+	}
 	return t.resolved.singular
 }
 
 func (t *Term) Plural() string {
+	if t.external != nil {
+		return t.external.Plural
+	}
 	return t.resolved.plural
 }
 
+// Markup provides the markdown formatting characters
+// enclosing the term.
+func (t *Term) Markup() string {
+	if t.external != nil {
+		return t.external.Format
+	}
+	return t.resolved.format
+}
+
 func (t *Term) Format() string {
-	f := t.resolved.format
+	f := t.Markup()
 	return f + t.Get() + reverse(f)
 }
 
 func (t *Term) FormatSingular() string {
-	f := t.resolved.format
+	f := t.Markup()
 	return f + t.Singular() + reverse(f)
 }
 
 func (t *Term) Get() string {
-	term := t.resolved.singular
+	term := t.Singular()
 	if t.plural {
-		term = t.resolved.plural
+		term = t.Plural()
 	}
 	if t.upper {
 		r, i := utf8.DecodeRuneInString(term)
@@ -93,20 +119,33 @@ func (t *Term) Get() string {
 }
 
 func (t *Term) Resolve(ctx scanner.ResolutionContext) error {
+	if t.project != "" {
+		ext, err := ctx.LookupExternalTerm(t.project, t.tag)
+		if err != nil {
+			return err
+		}
+		t.external = ext
+		return nil
+	}
 	rctx := ctx.LookupTag(GT_TERM, t.tag)
 	if rctx == nil {
-		return fmt.Errorf("unknown term %q", t.tag)
+		return fmt.Errorf("unknown term %q%s", t.tag, utils2.DidYouMean(t.tag, ctx.TagCandidates(GT_TERM)))
 	}
 	t.resolved = rctx.(*TermDefNodeContext)
 	return nil
 }
 
 func (t *Term) GetLink() utils2.Link {
+	if t.external != nil {
+		return t.external.Link
+	}
 	return t.resolved.GetLink()
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// MapTermTag maps a term reference ([*][^][<project>:]<tag>)
+// to a TermRef. The modifiers precede an optional project prefix.
 func MapTermTag(t string) TermRef {
 	term := TermRef{}
 	term.upper = false
@@ -114,9 +153,12 @@ func MapTermTag(t string) TermRef {
 	if term.plural = strings.HasPrefix(t, "*"); term.plural {
 		t = t[1:]
 	}
-	if term.upper = strings.HasPrefix(t, "^"); term.upper {
+	term.upper = strings.HasPrefix(t, "^")
+	if term.upper {
 		t = t[1:]
-	} else {
+	}
+	term.project, t = utils2.SplitProjectPrefix(t)
+	if !term.upper {
 		r, _ := utf8.DecodeRuneInString(t)
 		term.upper = unicode.IsUpper(r)
 	}
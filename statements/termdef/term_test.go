@@ -0,0 +1,37 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package termdef_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/mdgen/statements/termdef"
+)
+
+func expectRef(ref termdef.TermRef, project, tag, mode string) {
+	ExpectWithOffset(1, ref.Project()).To(Equal(project))
+	ExpectWithOffset(1, ref.Tag()).To(Equal(tag))
+	ExpectWithOffset(1, ref.Mode()).To(Equal(mode))
+}
+
+var _ = Describe("term references", func() {
+	It("maps local terms", func() {
+		expectRef(termdef.MapTermTag("statement"), "", "statement", "singular")
+		expectRef(termdef.MapTermTag("*statement"), "", "statement", "plural")
+		expectRef(termdef.MapTermTag("^statement"), "", "statement", "singular,upper")
+		expectRef(termdef.MapTermTag("*Statement"), "", "statement", "plural,upper")
+	})
+
+	It("maps external terms", func() {
+		expectRef(termdef.MapTermTag("platform:statement"), "platform", "statement", "singular")
+		expectRef(termdef.MapTermTag("*platform:statement"), "platform", "statement", "plural")
+		expectRef(termdef.MapTermTag("^platform:statement"), "platform", "statement", "singular,upper")
+		expectRef(termdef.MapTermTag("*^platform:statement"), "platform", "statement", "plural,upper")
+		expectRef(termdef.MapTermTag("platform:Statement"), "platform", "statement", "singular,upper")
+	})
+})
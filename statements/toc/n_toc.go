@@ -149,7 +149,7 @@ func (n *tocnode) Emit(ctx scanner.ResolutionContext) error {
 				cnt++
 			}
 		}
-		fmt.Printf("found %d top level %s entries\n", cnt, n.typ)
+		ctx.Logger().Debugf("%s: found %d top level %s entries", n.Location(), cnt, n.typ)
 		if cnt == 1 {
 			list = list[1:]
 			if len(list) == 0 {
@@ -183,8 +183,7 @@ func (n *tocnode) Emit(ctx scanner.ResolutionContext) error {
 			if info.Label().Name() != "" {
 				title = info.Label().Name() + " " + title
 			}
-			gap := fmt.Sprintf("%*s", (lvl-offset[lvl])*2+2, "")
-			fmt.Fprintf(w, "%s [%s](%s)<br>\n", strings.ReplaceAll(gap, " ", "&nbsp;&nbsp;"), title, link)
+			ctx.Renderer().TOCEntry(w, lvl-offset[lvl], title, link)
 		}
 	}
 	return nil
@@ -204,6 +203,9 @@ func (e TocEntry) Level() int {
 func (e TocEntry) Id() scanner.TaggedId {
 	return e.id
 }
+func (e TocEntry) Info() scanner.TreeLabelInfo {
+	return e.info
+}
 
 func TreeTOCIds(ctx scanner.ResolutionContext, typ string) []TocEntry {
 	return prepareTOCIds(ctx.GetIdsForTypeInTree(typ))
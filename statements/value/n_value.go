@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/mandelsoft/mdgen/scanner"
+	"github.com/mandelsoft/mdgen/utils"
 )
 
 func init() {
@@ -43,11 +44,15 @@ func (s *Statement) Start(p scanner.Parser, e scanner.Element) (scanner.Element,
 		if !scanner.ContextAttrs[tag] {
 			return nil, e.Errorf("unknown scope attribute %q", tag)
 		}
-	} else {
+	} else if scanner.Lookup[scanner.BlockNode](p) != nil {
+		// outside of blocks predefined values are checked
+		// during the registration
+		var names []string
 		checkParam := func(b scanner.BlockNode) (bool, error) {
 			if b == nil {
-				return true, e.Errorf("parameter %q not defined in static scopes", tag)
+				return true, e.Errorf("parameter %q not defined in static scopes%s", tag, utils.DidYouMean(tag, names))
 			}
+			names = append(names, b.GetParameterNames()...)
 			return b.HasParam(tag), nil
 		}
 
@@ -110,7 +115,7 @@ func (n *valuenode) Register(ctx scanner.ResolutionContext) error {
 	if !n.attr {
 		v = ctx.LookupValue(n.tag)
 		if v == nil {
-			n.Errorf("parameter %q not defined", n.tag)
+			return n.Errorf("parameter %q not defined%s", n.tag, utils.DidYouMean(n.tag, ctx.ValueCandidates()))
 		}
 	}
 	nctx := NewValueNodeContext(n, ctx, v)
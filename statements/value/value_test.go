@@ -0,0 +1,37 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package value_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/mdgen/scanner"
+	_ "github.com/mandelsoft/mdgen/statements"
+)
+
+func parse(src string) error {
+	_, err := scanner.NewParser("doc.mdg", "/doc", bytes.NewBufferString(src)).Parse()
+	return err
+}
+
+var _ = Describe("value statement", func() {
+	It("accepts parameters of enclosing blocks", func() {
+		Expect(parse("{{block /outer}}{{param foo}}{{block inner}}{{param bar}}{{value foo}}{{value bar}}{{endblock}}{{endblock}}\n")).To(Succeed())
+	})
+
+	It("accepts values outside of blocks", func() {
+		Expect(parse("{{value product}}\n")).To(Succeed())
+	})
+
+	It("rejects undefined parameters in unreferenced blocks", func() {
+		Expect(parse("{{block /outer}}{{param foo}}{{block inner}}{{param bar}}{{value fo}}{{endblock}}{{endblock}}\n")).To(
+			MatchError(`doc.mdg: line 1, column 58: parameter "fo" not defined in static scopes (did you mean "foo"?)`))
+	})
+})
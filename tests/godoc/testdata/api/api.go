@@ -0,0 +1,38 @@
+// Package api provides access to [File] objects.
+//
+// Use [Open] to get a file and [File.Name] to get its name.
+// Errors are described by [io.EOF].
+package api
+
+import "io"
+
+// Mode describes the access mode.
+type Mode int
+
+// The supported modes.
+const (
+	READ Mode = iota // read access
+	WRITE
+)
+
+// Open opens a file with a [Mode].
+func Open(name string, mode Mode) (*File, error) {
+	return &File{name}, nil
+}
+
+// File is an open file.
+type File struct {
+	// Path is the file path.
+	Path string
+	name string
+}
+
+// NewFile creates a [File].
+func NewFile(name string) *File { return nil }
+
+// Name provides the name of the file.
+func (f *File) Name() string {
+	return f.name
+}
+
+var _ io.Reader
@@ -0,0 +1,36 @@
+package api
+
+// Mode describes the access mode.
+type Mode int
+
+// The supported modes.
+const (
+	READ Mode = iota
+	WRITE
+)
+
+type (
+	// Reader reads data.
+	Reader interface {
+		Read() ([]byte, error)
+	}
+	// Writer writes data.
+	Writer interface {
+		Write(data []byte) error
+	}
+)
+
+// Open opens a file.
+func Open(name string, mode Mode) (*File, error) {
+	return &File{name}, nil
+}
+
+// File is an open file.
+type File struct {
+	name string
+}
+
+// Name provides the name of the file.
+func (f *File) Name() string {
+	return f.name
+}
@@ -0,0 +1,132 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree
+
+import (
+	"bytes"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/mdgen/utils"
+)
+
+type DiffKind string
+
+const (
+	DIFF_ADDED   DiffKind = "added"
+	DIFF_CHANGED DiffKind = "changed"
+	DIFF_STALE   DiffKind = "stale"
+)
+
+// Difference describes a target file, whose content differs from
+// the content generated for the source tree.
+type Difference struct {
+	Kind DiffKind
+	Path string
+}
+
+// Compare compares the content of a generated in-memory target tree with
+// the actual target folder. Documents or resources missing in the target folder
+// are reported as added, differing content as changed. Generated files (documents
+// and internalized resources) found in the target folder, which are not generated
+// anymore, are reported as stale. If the target folder provides a manifest,
+// only files listed there are considered as generated files.
+func Compare(w MemoryTreeWriter, fss ...vfs.FileSystem) ([]Difference, error) {
+	fs := utils.OptionalDefaulted(osfs.New(), fss...)
+
+	var result []Difference
+
+	generated := utils.Set[string]{}
+	for _, p := range w.Files() {
+		c, err := vfs.Canonical(fs, p, false)
+		if err != nil {
+			return nil, err
+		}
+		generated.Add(c)
+
+		data, _ := w.Content(p)
+		old, err := vfs.ReadFile(fs, p)
+		if err != nil {
+			if vfs.IsErrNotExist(err) {
+				result = append(result, Difference{DIFF_ADDED, p})
+				continue
+			}
+			return nil, err
+		}
+		if !bytes.Equal(old, data) {
+			result = append(result, Difference{DIFF_CHANGED, p})
+		}
+	}
+
+	manifest, err := ReadManifest(fs, w.Root())
+	if err != nil {
+		return nil, err
+	}
+	if manifest != nil {
+		for _, e := range manifest {
+			p := path.Join(w.Root(), e)
+			if ok, err := checkStale(fs, p, generated); err != nil {
+				return nil, err
+			} else if ok {
+				result = append(result, Difference{DIFF_STALE, p})
+			}
+		}
+	} else {
+		ok, err := vfs.DirExists(fs, w.Root())
+		if err != nil || !ok {
+			return result, err
+		}
+		resources := path.Join(w.Root(), "_resources")
+		err = walkFiles(fs, w.Root(), func(p string) error {
+			if !strings.HasSuffix(p, ".md") && !strings.HasPrefix(p, resources+"/") {
+				return nil
+			}
+			ok, err := checkStale(fs, p, generated)
+			if ok {
+				result = append(result, Difference{DIFF_STALE, p})
+			}
+			return err
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result, err
+}
+
+func checkStale(fs vfs.FileSystem, p string, generated utils.Set[string]) (bool, error) {
+	ok, err := vfs.FileExists(fs, p)
+	if err != nil || !ok {
+		return false, err
+	}
+	c, err := vfs.Canonical(fs, p, false)
+	if err != nil {
+		return false, err
+	}
+	return !generated.Has(c), nil
+}
+
+func walkFiles(fs vfs.FileSystem, p string, f func(p string) error) error {
+	list, err := vfs.ReadDir(fs, p)
+	if err != nil {
+		return err
+	}
+	for _, e := range list {
+		n := path.Join(p, e.Name())
+		if e.IsDir() {
+			err = walkFiles(fs, n, f)
+		} else {
+			err = f(n)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mandelsoft/mdgen/scanner"
+	"github.com/mandelsoft/mdgen/statements/termdef"
+	"github.com/mandelsoft/mdgen/statements/toc"
+	"github.com/mandelsoft/mdgen/utils"
+)
+
+// Model describes the resolved document model of a tree.
+// It can be used by other tools to reuse the knowledge about
+// a source tree without parsing the source documents.
+type Model struct {
+	Documents    []ModelDocument    `json:"documents"`
+	Sections     []ModelSection     `json:"sections"`
+	References   []ModelReference   `json:"references"`
+	Terms        []ModelTerm        `json:"terms"`
+	Blocks       []ModelBlock       `json:"blocks"`
+	NumberRanges []ModelNumberRange `json:"numberRanges"`
+}
+
+// ModelDocument describes a source document with its position
+// in the document structure.
+type ModelDocument struct {
+	Source        string `json:"source"`
+	RefPath       string `json:"refPath"`
+	TargetRefPath string `json:"targetRefPath"`
+	Template      bool   `json:"template,omitempty"`
+	Root          string `json:"root,omitempty"`
+	Parent        string `json:"parent,omitempty"`
+	Label         string `json:"label,omitempty"`
+	Title         string `json:"title,omitempty"`
+}
+
+// ModelSection describes a section in the order of the table of
+// contents of its root document. The level starts with 0 for the
+// top level sections of a root document.
+type ModelSection struct {
+	RefPath string   `json:"refPath"`
+	Level   int      `json:"level"`
+	Label   string   `json:"label,omitempty"`
+	Title   string   `json:"title"`
+	Anchors []string `json:"anchors,omitempty"`
+	Link    string   `json:"link"`
+}
+
+// ModelReference describes an entry of the reference index:
+// a link usable to refer to a referencable element and the
+// document and anchor it is resolved to.
+type ModelReference struct {
+	Link          string `json:"link"`
+	Type          string `json:"type,omitempty"`
+	Label         string `json:"label,omitempty"`
+	Title         string `json:"title,omitempty"`
+	RefPath       string `json:"refPath"`
+	TargetRefPath string `json:"targetRefPath"`
+	Anchor        string `json:"anchor,omitempty"`
+}
+
+// ModelTerm describes a globally defined term. The glossary text is
+// the markdown generated for the term by a glossary, it is empty if
+// the term is not described by a glossary.
+type ModelTerm struct {
+	Tag      string `json:"tag"`
+	Singular string `json:"singular"`
+	Plural   string `json:"plural"`
+	Link     string `json:"link"`
+	RefPath  string `json:"refPath"`
+	Anchor   string `json:"anchor,omitempty"`
+	Glossary string `json:"glossary,omitempty"`
+}
+
+// ModelBlock describes a text module defined at the top level
+// of a document together with its parameters.
+type ModelBlock struct {
+	Tag        string   `json:"tag"`
+	RefPath    string   `json:"refPath"`
+	Location   string   `json:"location"`
+	Parameters []string `json:"parameters,omitempty"`
+}
+
+// ModelNumberRange describes a number range of a root document
+// with its label rule.
+type ModelNumberRange struct {
+	Root   string `json:"root"`
+	Type   string `json:"type"`
+	Format string `json:"format"`
+	Level  int    `json:"level"`
+	Abbrev string `json:"abbrev,omitempty"`
+	Master string `json:"master,omitempty"`
+}
+
+// Model provides the resolved document model of the tree.
+func (t *tree) Model() (*Model, error) {
+	m := &Model{}
+	if t.resolution == nil {
+		return m, nil
+	}
+	res := t.resolution
+	names := utils.StringMapKeys(res.documents)
+
+	for _, n := range names {
+		di := res.documents[n]
+		d := ModelDocument{
+			Source:        di.Source(),
+			RefPath:       di.GetRefPath(),
+			TargetRefPath: di.GetTargetRefPath(),
+			Template:      di.document.IsTemplate(),
+			Title:         *di.Title(),
+		}
+		if l := di.Label(); l != nil {
+			d.Label = l.Name()
+		}
+		if di.rootinfo != nil && !di.IsRoot() {
+			d.Root = di.rootinfo.docinfo.GetRefPath()
+		}
+		if di.structinfo != nil {
+			d.Parent = di.structinfo.docinfo.GetRefPath()
+		}
+		m.Documents = append(m.Documents, d)
+
+		anchors := di.document.GetInventory().GetBlockAnchors()
+		sort.Strings(anchors)
+		for _, a := range anchors {
+			b := di.context.GetBlock(a)
+			if b == nil {
+				continue
+			}
+			m.Blocks = append(m.Blocks, ModelBlock{
+				Tag:        a,
+				RefPath:    di.GetRefPath(),
+				Location:   b.Location().String(),
+				Parameters: b.GetParameterNames(),
+			})
+		}
+	}
+
+	for _, n := range names {
+		di := res.documents[n]
+		if di.rootinfo == nil || !di.IsRoot() || di.document.IsTemplate() {
+			continue
+		}
+		list := toc.TreeTOCIds(di.context, scanner.SECTION_TYPE)
+		minlvl := 0
+		if len(list) > 0 {
+			minlvl = list[0].Level()
+		}
+		for _, e := range list {
+			info := e.Info()
+			s := ModelSection{
+				RefPath: info.GetRefPath(),
+				Level:   e.Level() - minlvl,
+				Anchors: info.Anchors(),
+				Link:    info.Link().String(),
+			}
+			if t := info.Title(); t != nil {
+				s.Title = *t
+			}
+			if l := info.Label(); l != nil {
+				s.Label = l.Name()
+			}
+			m.Sections = append(m.Sections, s)
+		}
+
+		for _, typ := range utils.StringMapKeys(di.rootinfo.ranges) {
+			nr := di.rootinfo.ranges[typ]
+			r := ModelNumberRange{
+				Root:   di.GetRefPath(),
+				Type:   typ,
+				Level:  nr.Level(),
+				Abbrev: nr.Abbrev(),
+				Master: nr.master,
+			}
+			if rule := nr.GetRule(); rule != nil {
+				r.Format = rule.Format()
+			}
+			m.NumberRanges = append(m.NumberRanges, r)
+		}
+	}
+
+	for l, r := range res.refindex {
+		ref := ModelReference{
+			Link:          l.String(),
+			RefPath:       r.GetRefPath(),
+			TargetRefPath: r.GetTargetRefPath(),
+			Anchor:        r.Anchor(),
+		}
+		if t := r.Title(); t != nil {
+			ref.Title = *t
+		}
+		if lab := r.Label(); lab != nil {
+			ref.Type = lab.Type()
+			ref.Label = lab.Name()
+		}
+		m.References = append(m.References, ref)
+	}
+	sort.Slice(m.References, func(i, j int) bool { return m.References[i].Link < m.References[j].Link })
+
+	for _, c := range res.GetGlobalTags(termdef.GT_TERM) {
+		nctx := c.(*termdef.TermDefNodeContext)
+		term := ModelTerm{
+			Tag:      nctx.Term().Tag(),
+			Singular: nctx.Term().Singular(),
+			Plural:   nctx.Term().Plural(),
+			Link:     nctx.GetLink().String(),
+		}
+		if r := res.refindex[nctx.GetLink()]; r != nil {
+			term.RefPath = r.GetRefPath()
+			term.Anchor = r.Anchor()
+		}
+		if gctx := nctx.GetGlossaryContext(); gctx != nil {
+			buf := scanner.NewBufferContext(gctx)
+			err := nctx.GetNodeSequence().Emit(buf)
+			if err != nil {
+				return nil, err
+			}
+			term.Glossary = strings.TrimSpace(buf.String())
+		}
+		m.Terms = append(m.Terms, term)
+	}
+	sort.Slice(m.Terms, func(i, j int) bool { return m.Terms[i].Tag < m.Terms[j].Tag })
+	return m, nil
+}
@@ -0,0 +1,78 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree_test
+
+import (
+	"encoding/json"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/mdgen/logging"
+	"github.com/mandelsoft/mdgen/tree"
+)
+
+var _ = Describe("export", func() {
+	It("exports the document model as JSON", func() {
+		fs := memoryfs.New()
+		Expect(fs.MkdirAll("/src", 0o755)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/src/README.mdg", []byte(`{{numberrange figure}}
+{{section intro}}Introduction
+A {{termdef tool}}tool{{description}}A program generating documents.{{endtermdef}}.
+{{sectionref sub}}
+{{endsection}}
+{{block /note}}{{param text}}Note: {{value text}}{{endblock}}
+`), 0o644)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/src/sub.mdg", []byte("{{section details}}Details\n{{endsection}}\n"), 0o644)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/src/glossary.mdg", []byte("{{section glossary}}Glossary\n{{glossary}}\n{{endsection}}\n"), 0o644)).To(Succeed())
+
+		t, err := tree.ForFolderWithLogger("/src", logging.Discard(), fs)
+		Expect(err).To(Succeed())
+		Expect(t.Resolve()).To(Succeed())
+		m, err := t.Model()
+		Expect(err).To(Succeed())
+		data, err := json.Marshal(m)
+		Expect(err).To(Succeed())
+
+		var model map[string]json.RawMessage
+		Expect(json.Unmarshal(data, &model)).To(Succeed())
+		Expect(model).To(HaveLen(6))
+		Expect(model["documents"]).To(MatchJSON(`[
+  {"source": "/src/README.mdg", "refPath": "/README", "targetRefPath": "/README", "label": "1", "title": "Introduction"},
+  {"source": "/src/glossary.mdg", "refPath": "/glossary", "targetRefPath": "/glossary", "label": "1", "title": "Glossary"},
+  {"source": "/src/sub.mdg", "refPath": "/sub", "targetRefPath": "/sub", "root": "/README", "parent": "/README", "label": "1.1", "title": "Details"}
+]`))
+		Expect(model["sections"]).To(MatchJSON(`[
+  {"refPath": "/README", "level": 0, "label": "1", "title": "Introduction", "anchors": ["intro", "section-1"], "link": "/README#intro"},
+  {"refPath": "/sub", "level": 1, "label": "1.1", "title": "Details", "anchors": ["details", "section-1"], "link": "/sub#details"},
+  {"refPath": "/glossary", "level": 0, "label": "1", "title": "Glossary", "anchors": ["glossary", "section-1"], "link": "/glossary#glossary"}
+]`))
+		Expect(m.References).To(HaveLen(9))
+		Expect(m.References[1]).To(Equal(tree.ModelReference{
+			Link:          "/README#intro",
+			Type:          "section",
+			Label:         "1",
+			Title:         "Introduction",
+			RefPath:       "/README",
+			TargetRefPath: "/README",
+			Anchor:        "intro",
+		}))
+		Expect(model["terms"]).To(MatchJSON(`[
+  {"tag": "tool", "singular": "tool", "plural": "tools", "link": "/README#intro", "refPath": "/README", "anchor": "intro", "glossary": "A program generating documents."}
+]`))
+		Expect(model["blocks"]).To(MatchJSON(`[
+  {"tag": "/note", "refPath": "/README", "location": "/src/README.mdg: line 6, column 1", "parameters": ["text"]}
+]`))
+		Expect(model["numberRanges"]).To(MatchJSON(`[
+  {"root": "/README", "type": "figure", "format": "numbered", "level": 0},
+  {"root": "/README", "type": "section", "format": "numbered", "level": 0},
+  {"root": "/glossary", "type": "section", "format": "numbered", "level": 0}
+]`))
+	})
+})
@@ -0,0 +1,93 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mandelsoft/mdgen/scanner"
+	"github.com/mandelsoft/mdgen/utils"
+)
+
+// SetFrontMatter enables the generation of YAML front matter for
+// the generated documents, which can be consumed by static site generators.
+func (t *tree) SetFrontMatter(b bool) {
+	t.frontmatter = b
+}
+
+// frontMatterFields lists the standard front matter fields
+// in the order they are emitted.
+var frontMatterFields = []string{"title", "weight", "parent", "description"}
+
+// emitFrontMatter emits the YAML front matter for a generated document.
+// It describes the title of the top level section, the parent document,
+// a weight describing the position in the section order of the parent
+// document and the fields explicitly set with the frontmatter directive.
+func (t *tree) emitFrontMatter(w scanner.Writer, di *DocumentInfo) error {
+	fields := &yaml.Node{Kind: yaml.MappingNode}
+	add := func(name, value, tag string) {
+		fields.Content = append(fields.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: name},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: value, Tag: tag},
+		)
+	}
+
+	explicit := di.document.GetFrontMatter()
+	standard := map[string]string{}
+	if *di.Title() != "" {
+		standard["title"] = *di.Title()
+	}
+	if p := di.structinfo; p != nil && !t.single {
+		for i, ref := range p.docinfo.context.docrefs.order {
+			if ref.docinfo == di {
+				standard["weight"] = strconv.Itoa(i + 1)
+			}
+		}
+		standard["parent"] = strings.TrimPrefix(t.resolution.outputRefPath(p.docinfo), "/") + t.resolution.extension
+	}
+
+	for _, n := range frontMatterFields {
+		v, ok := explicit[n]
+		if !ok {
+			v, ok = standard[n]
+		}
+		if ok {
+			tag := "!!str"
+			if n == "weight" {
+				if _, err := strconv.Atoi(v); err == nil {
+					tag = "!!int"
+				}
+			}
+			add(n, v, tag)
+		}
+	}
+	for _, n := range utils.StringMapKeys(explicit) {
+		if !isStandardField(n) {
+			add(n, explicit[n], "!!str")
+		}
+	}
+
+	data, err := yaml.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("cannot marshal front matter for %s: %w", di.Source(), err)
+	}
+	fmt.Fprintf(w, "---\n%s---\n", string(data))
+	return nil
+}
+
+func isStandardField(name string) bool {
+	for _, n := range frontMatterFields {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mandelsoft/mdgen/render"
+)
+
+// headingAnchors maps the anchors of the generated documents to the
+// anchors generated by the markdown viewer for the headings. It is used
+// for renderers, which cannot emit explicit anchors (see render.HeadingAnchors).
+//
+// During the emission it acts as renderer marking the emitted anchors and
+// headings in the buffered documents, and links refer to anchors by
+// placeholders. After all documents have been emitted, the marks are
+// assigned to the heading anchors and the placeholders are replaced.
+// Anchors directly followed by a heading are mapped to the anchor of this
+// heading, all other anchors to the anchor of the preceding heading of the
+// document.
+type headingAnchors struct {
+	render.Renderer
+	headings render.HeadingAnchors
+
+	// marked anchors, slugs of marked headings and linked anchors
+	marked [][]string
+	slugs  []string
+	links  []anchorLink
+	docs   []*recordedDocument
+
+	// anchors maps the anchors of the generated documents
+	// (by target ref path) after all documents have been emitted.
+	anchors map[string]map[string]string
+}
+
+type anchorLink struct {
+	refpath string
+	anchor  string
+}
+
+func newHeadingAnchors(r render.Renderer, h render.HeadingAnchors) *headingAnchors {
+	return &headingAnchors{Renderer: r, headings: h}
+}
+
+// link provides the anchor used to link an anchor of the generated
+// document with the given target ref path. Without heading anchors
+// the anchor is kept.
+func (h *headingAnchors) link(refpath, anchor string) string {
+	if h == nil || anchor == "" {
+		return anchor
+	}
+	if h.anchors != nil {
+		return h.anchors[refpath][anchor]
+	}
+	h.links = append(h.links, anchorLink{refpath, anchor})
+	return mark("l", len(h.links)-1)
+}
+
+var markerExp = regexp.MustCompile("\x00([ahl])([0-9]+)\x00")
+
+func mark(kind string, n int) string {
+	return fmt.Sprintf("\x00%s%d\x00", kind, n)
+}
+
+func (h *headingAnchors) Anchors(w io.Writer, anchors []string) {
+	io.WriteString(w, mark("a", len(h.marked)))
+	h.marked = append(h.marked, anchors)
+	h.Renderer.Anchors(w, anchors)
+}
+
+func (h *headingAnchors) Heading(w io.Writer, level int, text string) {
+	io.WriteString(w, mark("h", len(h.slugs)))
+	h.slugs = append(h.slugs, h.headings.HeadingAnchor(text))
+	h.Renderer.Heading(w, level, text)
+}
+
+func (h *headingAnchors) GlossaryEntry(w io.Writer, text, link, anchor string) {
+	h.Anchors(w, []string{anchor})
+	io.WriteString(w, mark("h", len(h.slugs)))
+	h.slugs = append(h.slugs, h.headings.HeadingAnchor(text))
+	h.Renderer.GlossaryEntry(w, text, link, anchor)
+}
+
+// document provides a writer buffering a generated document
+// until all documents have been emitted. Without heading anchors
+// the document writer is kept.
+func (h *headingAnchors) document(refpath string, w io.WriteCloser) io.WriteCloser {
+	if h == nil {
+		return w
+	}
+	d := &recordedDocument{refpath: refpath, writer: w}
+	h.docs = append(h.docs, d)
+	return d
+}
+
+// Close assigns the marks of all emitted documents to the anchors
+// of their headings and writes the documents with the linked anchors.
+func (h *headingAnchors) Close() error {
+	h.anchors = map[string]map[string]string{}
+	for _, d := range h.docs {
+		h.assign(d.refpath, d.String())
+	}
+	for _, d := range h.docs {
+		data := markerExp.ReplaceAllStringFunc(d.String(), func(m string) string {
+			if m[1] != 'l' {
+				return ""
+			}
+			n, _ := strconv.Atoi(m[2 : len(m)-1])
+			l := h.links[n]
+			return h.anchors[l.refpath][l.anchor]
+		})
+		_, err := io.WriteString(d.writer, data)
+		err2 := d.writer.Close()
+		if err != nil {
+			return err
+		}
+		if err2 != nil {
+			return err2
+		}
+	}
+	return nil
+}
+
+// assign assigns the marks of a generated document
+// to the anchors of its headings.
+func (h *headingAnchors) assign(refpath string, data string) {
+	anchors := map[string]string{}
+	used := map[string]bool{}
+	heading := ""
+	var pending []string
+
+	flush := func() {
+		for _, a := range pending {
+			if _, ok := anchors[a]; !ok {
+				anchors[a] = heading
+			}
+		}
+		pending = nil
+	}
+
+	last := 0
+	for _, m := range markerExp.FindAllStringSubmatchIndex(data, -1) {
+		kind := data[m[2]:m[3]]
+		if kind == "l" {
+			continue
+		}
+		if strings.TrimSpace(markerExp.ReplaceAllString(data[last:m[0]], "")) != "" {
+			flush()
+		}
+		last = m[1]
+		n, _ := strconv.Atoi(data[m[4]:m[5]])
+		if kind == "a" {
+			pending = append(pending, h.marked[n]...)
+			continue
+		}
+		heading = h.slugs[n]
+		for i := 1; used[heading]; i++ {
+			heading = fmt.Sprintf("%s-%d", h.slugs[n], i)
+		}
+		used[heading] = true
+		flush()
+	}
+	flush()
+	h.anchors[refpath] = anchors
+}
+
+// recordedDocument buffers a generated document
+// until the heading anchors are known.
+type recordedDocument struct {
+	bytes.Buffer
+	refpath string
+	writer  io.WriteCloser
+}
+
+func (d *recordedDocument) Close() error {
+	return nil
+}
@@ -0,0 +1,281 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/mandelsoft/filepath/pkg/filepath"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	gmutil "github.com/yuin/goldmark/util"
+
+	"github.com/mandelsoft/mdgen/utils"
+)
+
+// HTML_EXTENSION is the file extension of generated HTML pages.
+const HTML_EXTENSION = ".html"
+
+// HTMLTreeWriter generates a static HTML site instead of a markdown
+// tree. Every generated document is converted into an HTML page with
+// a navigation sidebar built from the section structure (see Navigation).
+// Links between documents refer to the generated pages, resources are
+// copied like for the FileTreeWriter. Relative markdown links to
+// documents of the tree (see SetDocuments) are mapped to the
+// generated pages, also.
+type HTMLTreeWriter = *htmlTreeWriter
+
+type htmlTreeWriter struct {
+	*fileTreeWriter
+	nav       []NavEntry
+	documents utils.Set[string]
+	markdown  goldmark.Markdown
+}
+
+var _ DocumentExtension = (*htmlTreeWriter)(nil)
+
+func NewHTMLTreeWriter(path string, fss ...vfs.FileSystem) (HTMLTreeWriter, error) {
+	w, err := NewFileTreeWriter(path, fss...)
+	if err != nil {
+		return nil, err
+	}
+	hw := &htmlTreeWriter{
+		fileTreeWriter: w,
+		documents:      utils.Set[string]{},
+	}
+	hw.markdown = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithParserOptions(parser.WithASTTransformers(gmutil.Prioritized(&linkTransformer{hw}, 100))),
+		// the generated markdown uses embedded HTML
+		goldmark.WithRendererOptions(gmhtml.WithUnsafe()),
+	)
+	return hw, nil
+}
+
+// SetNavigation sets the navigation structure used for the
+// sidebar of the generated pages.
+func (w *htmlTreeWriter) SetNavigation(nav []NavEntry) {
+	w.nav = nav
+}
+
+// SetDocuments sets the target ref paths of the documents of the tree.
+// Relative links to the markdown files of these documents are mapped
+// to the generated pages.
+func (w *htmlTreeWriter) SetDocuments(refpaths []string) {
+	w.documents = utils.Set[string]{}
+	w.documents.Add(refpaths...)
+}
+
+func (w *htmlTreeWriter) DocumentExtension() string {
+	return HTML_EXTENSION
+}
+
+func (w *htmlTreeWriter) Document(refpath string) (io.WriteCloser, string, error) {
+	path := w.root + refpath + HTML_EXTENSION
+	err := w.fs.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, path, fmt.Errorf("cannot create dir %s: %w", filepath.Dir(path), err)
+	}
+	w.generated.Add(strings.TrimPrefix(refpath, "/") + HTML_EXTENSION)
+	return &htmlDocument{writer: w, refpath: refpath, target: &documentWriter{fs: w.fs, path: path}}, path, nil
+}
+
+// htmlDocument buffers the generated markdown and writes the
+// HTML page on Close.
+type htmlDocument struct {
+	bytes.Buffer
+	writer  *htmlTreeWriter
+	refpath string
+	target  *documentWriter
+}
+
+func (d *htmlDocument) Close() error {
+	var body bytes.Buffer
+	ctx := parser.NewContext()
+	ctx.Set(refpathKey, d.refpath)
+	err := d.writer.markdown.Convert(d.Bytes(), &body, parser.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("cannot convert %s: %w", d.refpath, err)
+	}
+	err = pageTemplate.Execute(&d.target.Buffer, &page{
+		Title:   d.writer.title(d.refpath),
+		Sidebar: template.HTML(d.writer.sidebar(d.refpath)),
+		Content: template.HTML(closeAnchors(body.String())),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot render %s: %w", d.refpath, err)
+	}
+	return d.target.Close()
+}
+
+var refpathKey = parser.NewContextKey()
+
+// linkTransformer maps the destinations of markdown links
+// in a converted document (see link).
+type linkTransformer struct {
+	writer *htmlTreeWriter
+}
+
+func (t *linkTransformer) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	refpath, _ := pc.Get(refpathKey).(string)
+	ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if l, ok := n.(*ast.Link); ok && entering {
+			l.Destination = []byte(t.writer.link(refpath, string(l.Destination)))
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// link maps a relative link to the markdown file of a document
+// of the tree to the generated page. Other links are kept.
+func (w *htmlTreeWriter) link(refpath string, link string) string {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "" || u.Host != "" || path.IsAbs(u.Path) || !strings.HasSuffix(u.Path, DEFAULT_EXTENSION) {
+		return link
+	}
+	doc := strings.TrimSuffix(u.Path, DEFAULT_EXTENSION)
+	if !w.documents.Has(path.Join(path.Dir(refpath), doc)) {
+		return link
+	}
+	u.Path = doc + HTML_EXTENSION
+	return u.String()
+}
+
+// title provides the page title for a document, which is the title
+// of its first section.
+func (w *htmlTreeWriter) title(refpath string) string {
+	for _, e := range w.nav {
+		if e.RefPath == refpath {
+			return plainText(e.Title)
+		}
+	}
+	return path.Base(refpath)
+}
+
+// sidebar renders the navigation structure as nested lists with links
+// relative to the given document.
+func (w *htmlTreeWriter) sidebar(refpath string) string {
+	var buf strings.Builder
+
+	lvl := -1
+	for _, e := range w.nav {
+		l := e.Level
+		if l > lvl+1 {
+			l = lvl + 1
+		}
+		if l > lvl {
+			buf.WriteString("<ul>\n")
+		} else {
+			buf.WriteString("</li>\n")
+			for ; lvl > l; lvl-- {
+				buf.WriteString("</ul>\n</li>\n")
+			}
+		}
+		lvl = l
+
+		link := ""
+		if e.RefPath != refpath {
+			rel, err := filepath.Rel(filepath.Dir(refpath), e.RefPath+HTML_EXTENSION)
+			if err != nil {
+				rel = e.RefPath + HTML_EXTENSION
+			}
+			link = rel
+		}
+		if e.Anchor != "" {
+			link += "#" + e.Anchor
+		}
+		class := ""
+		if e.RefPath == refpath {
+			class = ` class="current"`
+		}
+		title := plainText(e.Title)
+		if e.Label != "" {
+			title = e.Label + " " + title
+		}
+		fmt.Fprintf(&buf, "<li%s><a href=\"%s\">%s</a>", class, html.EscapeString(link), html.EscapeString(title))
+	}
+	if lvl >= 0 {
+		buf.WriteString("</li>\n")
+		for ; lvl > 0; lvl-- {
+			buf.WriteString("</ul>\n</li>\n")
+		}
+		buf.WriteString("</ul>\n")
+	}
+	return buf.String()
+}
+
+var htmlTag = regexp.MustCompile(`<[^<>]*>`)
+
+var plainMarkdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithRendererOptions(gmhtml.WithUnsafe()),
+)
+
+// plainText converts a markdown fragment (like a section title) into
+// plain text.
+func plainText(md string) string {
+	var buf bytes.Buffer
+	if plainMarkdown.Convert([]byte(md), &buf) != nil {
+		return md
+	}
+	return strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(buf.String(), "")))
+}
+
+var selfClosingAnchor = regexp.MustCompile(`<a(\s[^<>]*?)?\s*/>`)
+
+// closeAnchors replaces the self-closing anchor tags used in the generated
+// markdown, which are not valid HTML, by empty anchor elements.
+func closeAnchors(s string) string {
+	return selfClosingAnchor.ReplaceAllString(s, "<a$1></a>")
+}
+
+type page struct {
+	Title   string
+	Sidebar template.HTML
+	Content template.HTML
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="generator" content="mdgen">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; display: flex; font-family: sans-serif; line-height: 1.5; }
+nav { flex: 0 0 18em; padding: 1em; border-right: 1px solid #ddd; background: #f8f8f8; min-height: 100vh; }
+nav ul { list-style: none; padding-left: 1em; margin: 0; }
+nav > ul { padding-left: 0; }
+nav a { text-decoration: none; color: #333; }
+nav li.current > a { font-weight: bold; }
+main { flex: 1; padding: 1em 2em; max-width: 60em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ddd; padding: 0.3em 0.6em; }
+pre { background: #f4f4f4; padding: 0.5em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<nav>
+{{.Sidebar}}</nav>
+<main>
+{{.Content}}</main>
+</body>
+</html>
+`))
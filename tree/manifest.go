@@ -0,0 +1,63 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree
+
+import (
+	"bytes"
+	"path"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/mdgen/utils"
+)
+
+// MANIFEST is the name of the file in the target root
+// listing all files generated by mdgen.
+const MANIFEST = ".mdgen-manifest"
+
+const manifestHeader = "# files generated by mdgen, do not edit\n"
+
+// ReadManifest reads the list of generated files (relative to the target root)
+// from the manifest of a target tree. If there is no manifest, nil is returned.
+func ReadManifest(fs vfs.FileSystem, root string) ([]string, error) {
+	data, err := vfs.ReadFile(fs, path.Join(root, MANIFEST))
+	if err != nil {
+		if vfs.IsErrNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var result []string
+	for _, l := range strings.Split(string(data), "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		// never accept entries outside the target tree
+		l = path.Clean(l)
+		if path.IsAbs(l) || l == ".." || strings.HasPrefix(l, "../") || l == MANIFEST {
+			continue
+		}
+		result = append(result, l)
+	}
+	return result, nil
+}
+
+func writeManifest(fs vfs.FileSystem, root string, entries utils.Set[string]) error {
+	var buf bytes.Buffer
+	buf.WriteString(manifestHeader)
+	for _, e := range utils.StringMapKeys(entries) {
+		buf.WriteString(e + "\n")
+	}
+	p := path.Join(root, MANIFEST)
+	old, err := vfs.ReadFile(fs, p)
+	if err == nil && bytes.Equal(old, buf.Bytes()) {
+		return nil
+	}
+	return vfs.WriteFile(fs, p, buf.Bytes(), 0644)
+}
@@ -0,0 +1,84 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/mdgen/utils"
+)
+
+// MemoryTreeWriter keeps the generated target tree in memory.
+// It is used to compare the generated content with an existing
+// target tree without writing anything.
+type MemoryTreeWriter = *memoryTreeWriter
+
+type memoryTreeWriter struct {
+	root  string
+	files map[string][]byte
+}
+
+func NewMemoryTreeWriter(root string) MemoryTreeWriter {
+	return &memoryTreeWriter{
+		root:  root,
+		files: map[string][]byte{},
+	}
+}
+
+func (w *memoryTreeWriter) Root() string {
+	return w.root
+}
+
+func (w *memoryTreeWriter) Document(refpath string) (io.WriteCloser, string, error) {
+	path := w.root + refpath + DEFAULT_EXTENSION
+	return &memoryDocument{writer: w, path: path}, path, nil
+}
+
+func (w *memoryTreeWriter) Resource(fs vfs.FileSystem, src string, target string) error {
+	data, err := vfs.ReadFile(fs, src)
+	if err != nil {
+		return fmt.Errorf("cannot read resource %s: %w", src, err)
+	}
+	w.files[target] = data
+	return nil
+}
+
+func (w *memoryTreeWriter) File(name string, data []byte) error {
+	w.files[path.Join(w.root, name)] = data
+	return nil
+}
+
+func (w *memoryTreeWriter) Close() error {
+	return nil
+}
+
+// Files returns the sorted list of generated target files.
+func (w *memoryTreeWriter) Files() []string {
+	return utils.StringMapKeys(w.files)
+}
+
+// Content returns the generated content for a target file.
+func (w *memoryTreeWriter) Content(path string) ([]byte, bool) {
+	data, ok := w.files[path]
+	return data, ok
+}
+
+type memoryDocument struct {
+	bytes.Buffer
+	writer *memoryTreeWriter
+	path   string
+}
+
+func (d *memoryDocument) Close() error {
+	d.writer.files[d.path] = d.Bytes()
+	return nil
+}
@@ -0,0 +1,153 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// NAV_MKDOCS describes the nav section of an MkDocs configuration (mkdocs.yml).
+	NAV_MKDOCS = "mkdocs"
+	// NAV_DOCUSAURUS describes a Docusaurus sidebar definition (sidebars.json).
+	NAV_DOCUSAURUS = "docusaurus"
+)
+
+// NavFormats lists the supported formats for navigation files.
+var NavFormats = []string{NAV_MKDOCS, NAV_DOCUSAURUS}
+
+// MKDOCS_NAV is the key of the navigation in an MkDocs configuration.
+const MKDOCS_NAV = "nav"
+
+// navTitle provides the title of a document used for navigation entries.
+func navTitle(n DocumentNode) string {
+	if n.Label != "" {
+		return n.Label + " " + n.Title
+	}
+	return n.Title
+}
+
+// MkDocsNav provides the nav section of an MkDocs configuration for a
+// document structure. If a document has sub documents, it is described
+// by a section starting with the document itself.
+// If an existing configuration is given, its nav section is replaced and
+// all other settings are kept.
+func MkDocsNav(docs []DocumentNode, existing []byte) ([]byte, error) {
+	nav := mkdocsNav(docs)
+
+	var doc yaml.Node
+	if len(bytes.TrimSpace(existing)) > 0 {
+		err := yaml.Unmarshal(existing, &doc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MkDocs configuration: %w", err)
+		}
+	}
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("invalid MkDocs configuration: map expected")
+	}
+	cfg := doc.Content[0]
+	found := false
+	for i := 0; i+1 < len(cfg.Content); i += 2 {
+		if cfg.Content[i].Value == MKDOCS_NAV {
+			cfg.Content[i+1] = nav
+			found = true
+		}
+	}
+	if !found {
+		cfg.Content = append(cfg.Content, yamlString(MKDOCS_NAV), nav)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err := enc.Encode(&doc)
+	if err != nil {
+		return nil, err
+	}
+	enc.Close()
+	return buf.Bytes(), nil
+}
+
+func mkdocsNav(docs []DocumentNode) *yaml.Node {
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, d := range docs {
+		var value *yaml.Node
+		if len(d.Children) == 0 {
+			value = yamlString(d.Path)
+		} else {
+			value = mkdocsNav(d.Children)
+			self := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{yamlString(navTitle(d)), yamlString(d.Path)}}
+			value.Content = append([]*yaml.Node{self}, value.Content...)
+		}
+		list.Content = append(list.Content, &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{yamlString(navTitle(d)), value}})
+	}
+	return list
+}
+
+func yamlString(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+}
+
+// DocusaurusItem describes an entry of a Docusaurus sidebar.
+type DocusaurusItem struct {
+	Type  string           `json:"type"`
+	Id    string           `json:"id,omitempty"`
+	Label string           `json:"label,omitempty"`
+	Link  *DocusaurusItem  `json:"link,omitempty"`
+	Items []DocusaurusItem `json:"items,omitempty"`
+}
+
+// DocusaurusSidebars provides a Docusaurus sidebar definition for
+// a document structure. A document with sub documents is described by
+// a category linked to the document. If an existing definition is given,
+// only the sidebar with the given name is replaced.
+func DocusaurusSidebars(docs []DocumentNode, name string, existing []byte) ([]byte, error) {
+	sidebars := map[string]json.RawMessage{}
+	if len(bytes.TrimSpace(existing)) > 0 {
+		err := json.Unmarshal(existing, &sidebars)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Docusaurus sidebars: %w", err)
+		}
+	}
+	data, err := json.Marshal(docusaurusItems(docs))
+	if err != nil {
+		return nil, err
+	}
+	sidebars[name] = data
+	data, err = json.MarshalIndent(sidebars, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func docusaurusItems(docs []DocumentNode) []DocusaurusItem {
+	list := []DocusaurusItem{}
+	for _, d := range docs {
+		id := strings.TrimSuffix(d.Path, DEFAULT_EXTENSION)
+		if len(d.Children) == 0 {
+			list = append(list, DocusaurusItem{Type: "doc", Id: id, Label: navTitle(d)})
+		} else {
+			list = append(list, DocusaurusItem{
+				Type:  "category",
+				Label: navTitle(d),
+				Link:  &DocusaurusItem{Type: "doc", Id: id},
+				Items: docusaurusItems(d.Children),
+			})
+		}
+	}
+	return list
+}
@@ -0,0 +1,138 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree_test
+
+import (
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/mdgen/logging"
+	"github.com/mandelsoft/mdgen/tree"
+)
+
+var docs = []tree.DocumentNode{
+	{
+		Label: "1",
+		Title: "Introduction",
+		Path:  "README.md",
+		Children: []tree.DocumentNode{
+			{Label: "1.1", Title: "Setup", Path: "guide/setup.md"},
+		},
+	},
+	{Title: "Glossary", Path: "glossary.md"},
+}
+
+var _ = Describe("navigation files", func() {
+	It("generates an MkDocs navigation", func() {
+		data, err := tree.MkDocsNav(docs, nil)
+		Expect(err).To(Succeed())
+		Expect(string(data)).To(Equal(`nav:
+  - 1 Introduction:
+      - 1 Introduction: README.md
+      - 1.1 Setup: guide/setup.md
+  - Glossary: glossary.md
+`))
+	})
+
+	It("replaces the navigation of an MkDocs configuration", func() {
+		data, err := tree.MkDocsNav(docs, []byte("site_name: test\nnav:\n  - old.md\ntheme: material\n"))
+		Expect(err).To(Succeed())
+		Expect(string(data)).To(Equal(`site_name: test
+nav:
+  - 1 Introduction:
+      - 1 Introduction: README.md
+      - 1.1 Setup: guide/setup.md
+  - Glossary: glossary.md
+theme: material
+`))
+	})
+
+	It("rejects an invalid MkDocs configuration", func() {
+		_, err := tree.MkDocsNav(docs, []byte("- nav\n"))
+		Expect(err).To(MatchError("invalid MkDocs configuration: map expected"))
+	})
+
+	It("generates Docusaurus sidebars", func() {
+		data, err := tree.DocusaurusSidebars(docs, "docs", nil)
+		Expect(err).To(Succeed())
+		Expect(string(data)).To(Equal(`{
+  "docs": [
+    {
+      "type": "category",
+      "label": "1 Introduction",
+      "link": {
+        "type": "doc",
+        "id": "README"
+      },
+      "items": [
+        {
+          "type": "doc",
+          "id": "guide/setup",
+          "label": "1.1 Setup"
+        }
+      ]
+    },
+    {
+      "type": "doc",
+      "id": "glossary",
+      "label": "Glossary"
+    }
+  ]
+}
+`))
+	})
+
+	It("replaces a Docusaurus sidebar", func() {
+		data, err := tree.DocusaurusSidebars(docs, "docs", []byte(`{"api": ["api/index"], "docs": ["old"]}`))
+		Expect(err).To(Succeed())
+		Expect(string(data)).To(Equal(`{
+  "api": [
+    "api/index"
+  ],
+  "docs": [
+    {
+      "type": "category",
+      "label": "1 Introduction",
+      "link": {
+        "type": "doc",
+        "id": "README"
+      },
+      "items": [
+        {
+          "type": "doc",
+          "id": "guide/setup",
+          "label": "1.1 Setup"
+        }
+      ]
+    },
+    {
+      "type": "doc",
+      "id": "glossary",
+      "label": "Glossary"
+    }
+  ]
+}
+`))
+	})
+})
+
+var _ = Describe("document structure", func() {
+	It("provides the structure of a resolved tree", func() {
+		fs := memoryfs.New()
+		Expect(fs.MkdirAll("/src/guide", 0o755)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/src/README.mdg", []byte("{{section intro}}Introduction\n{{sectionref /guide/setup}}\n{{endsection}}\n"), 0o644)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/src/guide/setup.mdg", []byte("{{section setup}}Setup\n{{endsection}}\n"), 0o644)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/src/glossary.mdg", []byte("{{numberrange section:V}}\n{{section glossary}}Glossary\n{{endsection}}\n"), 0o644)).To(Succeed())
+
+		t, err := tree.ForFolderWithLogger("/src", logging.Discard(), fs)
+		Expect(err).To(Succeed())
+		Expect(t.Resolve()).To(Succeed())
+		Expect(t.DocumentStructure()).To(Equal(docs))
+	})
+})
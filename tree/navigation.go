@@ -0,0 +1,87 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree
+
+import (
+	"github.com/mandelsoft/mdgen/scanner"
+	"github.com/mandelsoft/mdgen/statements/toc"
+	"github.com/mandelsoft/mdgen/utils"
+)
+
+// NavEntry describes an entry of the navigation structure of a
+// resolved tree. It describes a section with its nesting level
+// (starting with 0 for the top level sections of a root document)
+// and the target document and anchor it is generated to.
+type NavEntry struct {
+	Level   int
+	Label   string
+	Title   string
+	RefPath string
+	Anchor  string
+}
+
+// Navigation provides the navigation structure of a resolved tree.
+// It is built from the sections of the root documents and their
+// structural sub documents in the order used by the toc statement.
+func (t *tree) Navigation() []NavEntry {
+	if t.resolution == nil {
+		return nil
+	}
+	var result []NavEntry
+	for _, n := range utils.StringMapKeys(t.resolution.documents) {
+		di := t.resolution.documents[n]
+		if di.rootinfo == nil || !di.IsRoot() || di.document.IsTemplate() {
+			continue
+		}
+		list := toc.TreeTOCIds(di.context, scanner.SECTION_TYPE)
+		if len(list) == 0 {
+			continue
+		}
+		minlvl := list[0].Level()
+		for _, e := range list {
+			info := e.Info()
+			title := ""
+			if t := info.Title(); t != nil {
+				title = *t
+			}
+			if title == "" {
+				continue
+			}
+			entry := NavEntry{
+				Level:   e.Level() - minlvl,
+				Title:   title,
+				RefPath: info.GetTargetRefPath(),
+			}
+			if l := info.Label(); l != nil {
+				entry.Label = l.Name()
+			}
+			if r, ok := t.resolution.refindex[info.Link()].(*resolvedRef); ok {
+				entry.RefPath = t.resolution.outputRefPath(r.ctx.docinfo)
+				entry.Anchor = t.resolution.outputAnchor(r.ctx.docinfo, r.Anchor())
+			}
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// Documents provides the target ref paths of all documents
+// generated for a resolved tree.
+func (t *tree) Documents() []string {
+	if t.resolution == nil {
+		return nil
+	}
+	var result []string
+	for _, n := range utils.StringMapKeys(t.resolution.documents) {
+		di := t.resolution.documents[n]
+		if di.document.IsTemplate() || (t.single && (di.rootinfo == nil || !di.IsRoot())) {
+			continue
+		}
+		result = append(result, di.document.GetTargetRefPath())
+	}
+	return result
+}
@@ -0,0 +1,303 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/mdgen/labels"
+	"github.com/mandelsoft/mdgen/scanner"
+	"github.com/mandelsoft/mdgen/statements/termdef"
+	utils2 "github.com/mandelsoft/mdgen/utils"
+)
+
+// INVENTORY is the name of the reference inventory written into
+// the root of a generated tree.
+const INVENTORY = "mdgen-inventory.json"
+
+// Inventory describes the referencable elements of a generated tree.
+// Other projects can import it under a project prefix to refer to
+// these elements with links like <prefix>:#/anchor.
+// The targets are links relative to the root of the generated tree.
+type Inventory struct {
+	References []InventoryReference `json:"references"`
+	Terms      []InventoryTerm      `json:"terms,omitempty"`
+}
+
+// InventoryReference describes a link usable to refer to a referencable
+// element together with its label, title and target.
+type InventoryReference struct {
+	Link   string `json:"link"`
+	Type   string `json:"type,omitempty"`
+	Label  string `json:"label,omitempty"`
+	Abbrev string `json:"abbrev,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Target string `json:"target"`
+}
+
+// InventoryTerm describes a globally defined term. The link
+// refers to an entry of the references.
+type InventoryTerm struct {
+	Tag      string `json:"tag"`
+	Singular string `json:"singular"`
+	Plural   string `json:"plural"`
+	Markup   string `json:"markup,omitempty"`
+	Link     string `json:"link"`
+}
+
+// ReadInventory reads a reference inventory. The location is either
+// a file path or an http(s) URL.
+func ReadInventory(location string, fss ...vfs.FileSystem) (*Inventory, error) {
+	var data []byte
+	var err error
+
+	if utils2.IsURL(location) {
+		data, err = download(location)
+	} else {
+		data, err = vfs.ReadFile(utils2.OptionalDefaulted(osfs.New(), fss...), location)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read inventory %s: %w", location, err)
+	}
+	var inv Inventory
+	err = json.Unmarshal(data, &inv)
+	if err != nil {
+		return nil, fmt.Errorf("invalid inventory %s: %w", location, err)
+	}
+	return &inv, nil
+}
+
+// DOWNLOAD_TIMEOUT limits the time used to download
+// a reference inventory.
+const DOWNLOAD_TIMEOUT = 30 * time.Second
+
+var client = &http.Client{Timeout: DOWNLOAD_TIMEOUT}
+
+func download(url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// SetInventory enables the generation of a reference inventory
+// (INVENTORY) into the root of the generated tree.
+func (t *tree) SetInventory(b bool) {
+	t.inventory = b
+}
+
+// ImportInventory imports the reference inventory of another project
+// under the given prefix. The targets of the inventory are resolved
+// relative to the URL of the published tree of the other project.
+func (t *tree) ImportInventory(prefix string, url string, inv *Inventory) {
+	if t.imported == nil {
+		t.imported = map[string]*importedInventory{}
+	}
+	t.imported[prefix] = &importedInventory{url: url, inventory: inv}
+}
+
+type importedInventory struct {
+	url       string
+	inventory *Inventory
+}
+
+// Inventory provides the reference inventory for the tree
+// generated by the last call to Emit.
+func (t *tree) Inventory() *Inventory {
+	res := t.resolution
+	inv := &Inventory{}
+	for l, r := range res.refindex {
+		ref := InventoryReference{
+			Link:   l.String(),
+			Abbrev: r.Abbrev(),
+			Target: res.rootLink(r),
+		}
+		if t := r.Title(); t != nil {
+			ref.Title = *t
+		}
+		if lab := r.Label(); lab != nil {
+			ref.Type = lab.Type()
+			ref.Label = lab.Name()
+		}
+		inv.References = append(inv.References, ref)
+	}
+	sort.Slice(inv.References, func(i, j int) bool { return inv.References[i].Link < inv.References[j].Link })
+
+	for _, c := range res.GetGlobalTags(termdef.GT_TERM) {
+		nctx := c.(*termdef.TermDefNodeContext)
+		inv.Terms = append(inv.Terms, InventoryTerm{
+			Tag:      nctx.Term().Tag(),
+			Singular: nctx.Term().Singular(),
+			Plural:   nctx.Term().Plural(),
+			Markup:   nctx.Term().Markup(),
+			Link:     nctx.GetLink().String(),
+		})
+	}
+	sort.Slice(inv.Terms, func(i, j int) bool { return inv.Terms[i].Tag < inv.Terms[j].Tag })
+	return inv
+}
+
+func (t *tree) emitInventory(tw TreeWriter) error {
+	fw, ok := tw.(FileWriter)
+	if !ok {
+		return fmt.Errorf("tree writer does not support a reference inventory")
+	}
+	data, err := json.MarshalIndent(t.Inventory(), "", "  ")
+	if err != nil {
+		return err
+	}
+	t.log.Infof("writing %s", INVENTORY)
+	return fw.File(INVENTORY, append(data, '\n'))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// importInventory adds the references and terms of an imported inventory
+// to the external index of the resolution.
+func (r *Resolution) importInventory(prefix string, imp *importedInventory) error {
+	base := strings.TrimSuffix(imp.url, "/") + "/"
+	for _, e := range imp.inventory.References {
+		l, err := utils2.ParseLink(e.Link)
+		if err != nil {
+			return fmt.Errorf("inventory %q: %w", prefix, err)
+		}
+		l = utils2.NewExternalLink(prefix, l)
+		r.external[l] = &externalRef{
+			link:   l,
+			label:  externalLabel{e.Type, e.Label},
+			abbrev: e.Abbrev,
+			title:  e.Title,
+			url:    base + e.Target,
+		}
+	}
+	terms := map[string]*scanner.ExternalTerm{}
+	for _, e := range imp.inventory.Terms {
+		l, err := utils2.ParseLink(e.Link)
+		if err != nil {
+			return fmt.Errorf("inventory %q: term %q: %w", prefix, e.Tag, err)
+		}
+		terms[e.Tag] = &scanner.ExternalTerm{
+			Singular: e.Singular,
+			Plural:   e.Plural,
+			Format:   e.Markup,
+			Link:     utils2.NewExternalLink(prefix, l),
+		}
+	}
+	r.externalterms[prefix] = terms
+	return nil
+}
+
+// rootLink provides the link for a resolved reference
+// relative to the root of the generated tree.
+func (r *Resolution) rootLink(resolved scanner.ResolvedRef) string {
+	rp, anchor := r.target(resolved)
+	return r.rootDocumentLink(rp, anchor)
+}
+
+// rootDocumentLink provides the link for an anchor in a generated
+// document relative to the root of the generated tree.
+func (r *Resolution) rootDocumentLink(rp, anchor string) string {
+	link := ""
+	if rp != "" {
+		if r.links != nil {
+			link = r.links.DocumentLink(rp)
+		} else {
+			link = strings.TrimPrefix(rp, "/") + r.extension
+		}
+	}
+	if anchor != "" {
+		return link + "#" + anchor
+	}
+	return link
+}
+
+// externalRef is a reference provided by the inventory
+// of another project. It is linked by its URL.
+type externalRef struct {
+	link   utils2.Link
+	label  externalLabel
+	abbrev string
+	title  string
+	url    string
+}
+
+var _ scanner.ResolvedRef = (*externalRef)(nil)
+
+func (e *externalRef) GetRefPath() string {
+	return e.link.Path()
+}
+
+func (e *externalRef) GetTargetRefPath() string {
+	return e.link.Path()
+}
+
+func (e *externalRef) Anchors() []string {
+	if a := e.link.Anchor(); a != "" {
+		return []string{a}
+	}
+	return nil
+}
+
+func (e *externalRef) Label() labels.Label {
+	return e.label
+}
+
+func (e *externalRef) Abbrev() string {
+	return e.abbrev
+}
+
+func (e *externalRef) Title() *string {
+	return &e.title
+}
+
+func (e *externalRef) Anchor() string {
+	return e.link.Anchor()
+}
+
+func (e *externalRef) Context() scanner.ResolutionContext {
+	return nil
+}
+
+// externalLabel is the label of an element of another project
+// taken from its inventory.
+type externalLabel struct {
+	typ  string
+	name string
+}
+
+func (l externalLabel) Id() labels.LabelId {
+	return labels.NewLabelId(l.typ, 0)
+}
+
+func (l externalLabel) Type() string {
+	return l.typ
+}
+
+func (l externalLabel) Name() string {
+	return l.name
+}
+
+func (l externalLabel) Level() int {
+	if l.name == "" {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(l.name, "."), ".") + 1
+}
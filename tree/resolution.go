@@ -9,12 +9,15 @@ package tree
 import (
 	"fmt"
 	"path"
+	"sort"
 	"strings"
 
 	"github.com/mandelsoft/filepath/pkg/filepath"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 
 	"github.com/mandelsoft/mdgen/labels"
+	"github.com/mandelsoft/mdgen/logging"
+	"github.com/mandelsoft/mdgen/render"
 	"github.com/mandelsoft/mdgen/scanner"
 	utils2 "github.com/mandelsoft/mdgen/utils"
 )
@@ -161,25 +164,45 @@ type Resolution struct {
 	absroot  string
 	path     string
 	fs       vfs.VFS
+	log      logging.Logger
 
 	documents map[string]*DocumentInfo
 	blocktags map[string]*DocumentInfo
 
 	refindex map[utils2.Link]scanner.ResolvedRef
 
+	// external indexes the references and terms imported
+	// from the inventories of other projects.
+	external      map[utils2.Link]scanner.ResolvedRef
+	externalterms map[string]map[string]*scanner.ExternalTerm
+
 	tagged map[string]map[string]scanner.NodeContext
 
 	internalized  map[string]string
 	internalnames map[string]int
 
 	targetroot string
+	single     bool
+	writer     TreeWriter
+	extension  string
+	links      DocumentLinks
+	renderer   render.Renderer
+	anchors    *headingAnchors
+	// emitted are the anchors emitted by statements,
+	// which cannot register their anchors in advance
+	// (per ref path of the generated document).
+	emitted map[string]utils2.Set[string]
+
+	// dependencies are the files used to generate the documents
+	// besides the document sources.
+	dependencies utils2.Set[string]
 
 	current *DocumentInfo
 }
 
 var _ scanner.LookupScope = (*Resolution)(nil)
 
-func NewResolution(docs map[string]scanner.Document, path string, fs vfs.FileSystem, copy bool) (*Resolution, error) {
+func NewResolution(docs map[string]scanner.Document, path string, fs vfs.FileSystem, copy bool, log ...logging.Logger) (*Resolution, error) {
 	root, err := vfs.Canonical(fs, path, true)
 	if err != nil {
 		return nil, err
@@ -188,16 +211,25 @@ func NewResolution(docs map[string]scanner.Document, path string, fs vfs.FileSys
 		absroot:   root,
 		path:      path,
 		fs:        vfs.New(fs),
+		log:       utils2.OptionalDefaulted(logging.Default(), log...),
+		renderer:  render.Default(),
+		extension: DEFAULT_EXTENSION,
 		copymode:  copy,
 		documents: map[string]*DocumentInfo{},
 
 		blocktags: map[string]*DocumentInfo{},
 		refindex:  map[utils2.Link]scanner.ResolvedRef{},
 
+		external:      map[utils2.Link]scanner.ResolvedRef{},
+		externalterms: map[string]map[string]*scanner.ExternalTerm{},
+
 		tagged: map[string]map[string]scanner.NodeContext{},
 
 		internalized:  map[string]string{},
 		internalnames: map[string]int{},
+
+		dependencies: utils2.Set[string]{},
+		emitted:      map[string]utils2.Set[string]{},
 	}
 	for n, d := range docs {
 		di := NewDocumentInfo(res, d)
@@ -210,6 +242,65 @@ func NewResolution(docs map[string]scanner.Document, path string, fs vfs.FileSys
 	return res, nil
 }
 
+// SetRenderer sets the renderer used to emit the documents.
+func (r *Resolution) SetRenderer(renderer render.Renderer) {
+	r.renderer = renderer
+}
+
+// outputRefPath provides the target ref path of the generated
+// document containing the given document.
+func (r *Resolution) outputRefPath(di *DocumentInfo) string {
+	if r.single && di.rootinfo != nil {
+		di = di.rootinfo.docinfo
+	}
+	return di.document.GetTargetRefPath()
+}
+
+// outputAnchor provides the anchor used in the generated document for
+// an anchor of the given document. In single file mode document local
+// anchors are qualified by the document path to keep them unique, and
+// the document itself is addressed by the anchor of its top level section.
+func (r *Resolution) outputAnchor(di *DocumentInfo, anchor string) string {
+	if !r.single {
+		return anchor
+	}
+	if anchor == "" {
+		if di.rootnode == nil || di.IsRoot() {
+			return ""
+		}
+		anchors := di.context.GetReferencable(di.rootnode.Id()).Anchors()
+		if len(anchors) == 0 {
+			return ""
+		}
+		anchor = anchors[0]
+	}
+	if path.IsAbs(anchor) {
+		return anchor
+	}
+	return strings.TrimPrefix(di.GetRefPath(), "/") + ":" + anchor
+}
+
+// target provides the target ref path of the generated document
+// and the anchor used in this document for a resolved reference.
+func (r *Resolution) target(resolved scanner.ResolvedRef) (string, string) {
+	if rr, ok := resolved.(*resolvedRef); ok {
+		rp := r.outputRefPath(rr.ctx.docinfo)
+		return rp, r.anchors.link(rp, r.outputAnchor(rr.ctx.docinfo, rr.anchor))
+	}
+	return resolved.GetTargetRefPath(), resolved.Anchor()
+}
+
+// SetValues predefines values for all documents.
+func (r *Resolution) SetValues(values map[string]string) {
+	for _, di := range r.documents {
+		for n, v := range values {
+			seq := scanner.NewNodeSequence()
+			seq.AddNode(scanner.NewTextNode(di.document, di.document.Location(), v))
+			di.context.GetScope().SetValue(n, scanner.NewValue(di.context, seq))
+		}
+	}
+}
+
 func (r *Resolution) RegisterTag(typ string, tag string, nctx scanner.NodeContext, explicit bool) error {
 	// fmt.Printf("*** registering global %s %q\n", typ, tag)
 	m := r.tagged[typ]
@@ -268,13 +359,22 @@ func (r *Resolution) GetNamespace() string {
 }
 
 func (r *Resolution) LookupReferencable(link utils2.Link) scanner.RefInfo {
-	ri := r.refindex[link]
+	ri := r.lookup(link)
 	if ri == nil {
 		return nil
 	}
 	return ri
 }
 
+// lookup looks up a resolved reference for a link into the actual
+// tree or into the inventory of another project.
+func (r *Resolution) lookup(link utils2.Link) scanner.ResolvedRef {
+	if link.IsExternal() {
+		return r.external[link]
+	}
+	return r.refindex[link]
+}
+
 func (r *Resolution) LookupBlock(link utils2.Link) (scanner.BlockNodeContext, scanner.Scope) {
 	di, anchor := r.link(r.blocktags, link)
 	if di == nil {
@@ -287,6 +387,34 @@ func (r *Resolution) LookupValue(name string) *scanner.Value {
 	return nil
 }
 
+func (r *Resolution) TagCandidates(typ string) []string {
+	return utils2.StringMapKeys(r.tagged[typ])
+}
+
+func (r *Resolution) ReferencableCandidates() []string {
+	var result []string
+	for l := range r.refindex {
+		result = append(result, l.String())
+	}
+	for l := range r.external {
+		result = append(result, l.String())
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (r *Resolution) BlockCandidates() []string {
+	var result []string
+	for _, t := range utils2.StringMapKeys(r.blocktags) {
+		result = append(result, utils2.NewTagLink(t).String())
+	}
+	return result
+}
+
+func (r *Resolution) ValueCandidates() []string {
+	return nil
+}
+
 func (r *Resolution) RegisterReferencable(nctx scanner.LabeledNodeContext, tags []string, explicit bool) (scanner.RefInfo, error) {
 	ti := scanner.NewRefInfo(nctx, tags)
 	ctx := r.documents[nctx.GetDocument().GetRefPath()].context
@@ -300,10 +428,10 @@ func (r *Resolution) RegisterReferencable(nctx scanner.LabeledNodeContext, tags
 			if ri := r.refindex[l]; ri != nil {
 				return nil, fmt.Errorf("duplicate definition of tag %q: %s and %s", t, ri.Context().GetDocument().Source(), nctx.GetDocument().Source())
 			}
-			fmt.Printf("%s: found absolute tag %q\n", nctx.GetDocument().Source(), t)
+			r.log.Debugf("%s: found absolute tag %q", nctx.GetDocument().Source(), t)
 		} else {
 			l = utils2.NewLink(ctx.GetDocument().GetRefPath(), t)
-			fmt.Printf("%s: found document tag %q\n", nctx.GetDocument().Source(), l)
+			r.log.Debugf("%s: found document tag %q", nctx.GetDocument().Source(), l)
 		}
 		r.refindex[l] = NewResolvedRef(ctx, t, ti)
 	}
@@ -316,7 +444,7 @@ func (r *Resolution) RegisterBlock(anchor string, nctx scanner.BlockNodeContext)
 			return fmt.Errorf("duplicate definition of block %q: %s and %s", anchor, di.document.Source(), nctx.GetDocument().Source())
 		}
 		r.blocktags[anchor] = r.documents[nctx.GetDocument().GetRefPath()]
-		fmt.Printf("%s: found absolute block %q\n", nctx.GetDocument().Source(), anchor)
+		r.log.Debugf("%s: found absolute block %q", nctx.GetDocument().Source(), anchor)
 	}
 	return nil
 }
@@ -335,15 +463,11 @@ func (r *Resolution) InternalizeResource(rabs string) (string, error) {
 	} else {
 		n = fmt.Sprintf("%s_%03d", n, i)
 	}
-	err := r.fs.MkdirAll(r.fs.Join(r.targetroot, "_resources"), 0755)
+	n, err := r.fs.Canonical(r.fs.Join(r.targetroot, "_resources", n), false)
 	if err != nil {
 		return "", err
 	}
-	n, err = r.fs.Canonical(r.fs.Join(r.targetroot, "_resources", n), false)
-	if err != nil {
-		return "", err
-	}
-	return n, vfs.CopyFile(r.fs, rabs, r.fs, n)
+	return n, r.writer.Resource(r.fs, rabs, n)
 }
 
 type ids = scanner.Ids
@@ -419,6 +543,43 @@ func (r *ResolutionContext) Info(key string) interface{} {
 	return nil
 }
 
+func (r *ResolutionContext) Logger() logging.Logger {
+	return r.resolution.log
+}
+
+func (r *ResolutionContext) Renderer() render.Renderer {
+	return r.resolution.renderer
+}
+
+func (r *ResolutionContext) OutputAnchor(anchor string) string {
+	return r.resolution.outputAnchor(r.docinfo, anchor)
+}
+
+func (r *ResolutionContext) LinkAnchor(anchor string) string {
+	return r.resolution.anchors.link(r.resolution.outputRefPath(r.docinfo), anchor)
+}
+
+func (r *ResolutionContext) EmitAnchors(anchors ...string) []string {
+	rp := r.resolution.outputRefPath(r.docinfo)
+	emitted := r.resolution.emitted[rp]
+	if emitted == nil {
+		emitted = utils2.Set[string]{}
+		r.resolution.emitted[rp] = emitted
+	}
+	var result []string
+	for _, a := range anchors {
+		if !emitted.Has(a) {
+			emitted.Add(a)
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+func (r *ResolutionContext) AddDependency(path string) {
+	r.resolution.dependencies.Add(filepath.Clean(path))
+}
+
 func (r *ResolutionContext) CallStack() scanner.CallStack {
 	return r.callstack
 }
@@ -466,6 +627,21 @@ func (c *ResolutionContext) RequestDocument(link utils2.Link, loc scanner.Locati
 	return c.docrefs.Add(link, loc)
 }
 
+func (c *ResolutionContext) EmbedDocument(link utils2.Link, w scanner.Writer) (bool, error) {
+	if !c.resolution.single {
+		return false, nil
+	}
+	ref := c.docrefs.links[link]
+	if ref == nil || ref.docinfo == nil || ref.docinfo.document.IsTemplate() {
+		return true, nil
+	}
+	c.resolution.log.Debugf("  embedding %s", ref.docinfo.GetRefPath())
+	cur := c.resolution.current
+	defer func() { c.resolution.current = cur }()
+	fmt.Fprintf(w, "\n")
+	return true, ref.docinfo.Emit(w, c.target)
+}
+
 func (r *ResolutionContext) LookupTag(typ string, tag string) scanner.NodeContext {
 	return r.resolution.LookupTag(typ, tag)
 }
@@ -548,7 +724,7 @@ func (r *ResolutionContext) HandleResourceLinkPath(src, rp string) (string, erro
 				return "", err
 			}
 			if rel != ".." && !strings.HasPrefix(rel, "../") {
-				return rp, vfs.CopyFile(r.resolution.fs, rabs, r.resolution.fs, r.resolution.fs.Join(target, rp))
+				return rp, r.resolution.writer.Resource(r.resolution.fs, rabs, r.resolution.fs.Join(target, rp))
 			}
 		}
 		rp, err = r.resolution.InternalizeResource(rabs)
@@ -577,29 +753,48 @@ func (r *ResolutionContext) DetermineLinkPath(src, rp string) (string, error) {
 func (r *ResolutionContext) DetermineLink(l utils2.Link) (string, error) {
 	var err error
 
-	resolved := r.resolution.refindex[l]
+	resolved := r.resolution.lookup(l)
 
 	if resolved == nil {
-		return "", fmt.Errorf("cannot resolve link %s", l)
+		return "", fmt.Errorf("cannot resolve link %s%s", l, utils2.DidYouMean(l.String(), r.resolution.ReferencableCandidates()))
 	}
-	refpath := r.docinfo.document.GetTargetRefPath()
+	if ext, ok := resolved.(*externalRef); ok {
+		return ext.url, nil
+	}
+	refpath := r.resolution.outputRefPath(r.docinfo)
 
 	rel := ""
-	rp := resolved.GetTargetRefPath()
+	rp, anchor := r.resolution.target(resolved)
 	if rp != "" && refpath != rp {
-		rel, err = filepath.Rel(filepath.Dir(refpath), rp+".md")
-		if err != nil {
-			return "", fmt.Errorf("cannot determine relative file path for %s: %w", l, err)
+		if r.resolution.links != nil {
+			rel = r.resolution.links.DocumentLink(rp)
+		} else {
+			rel, err = filepath.Rel(filepath.Dir(refpath), rp+r.resolution.extension)
+			if err != nil {
+				return "", fmt.Errorf("cannot determine relative file path for %s: %w", l, err)
+			}
 		}
 	}
-	if resolved.Anchor() != "" {
-		return rel + "#" + resolved.Anchor(), nil
+	if anchor != "" {
+		return rel + "#" + anchor, nil
 	}
 	return rel, nil
 }
 
 func (r *ResolutionContext) GetLinkInfo(l utils2.Link) scanner.ResolvedRef {
-	return r.resolution.refindex[l]
+	return r.resolution.lookup(l)
+}
+
+func (r *ResolutionContext) LookupExternalTerm(project, tag string) (*scanner.ExternalTerm, error) {
+	terms, ok := r.resolution.externalterms[project]
+	if !ok {
+		return nil, fmt.Errorf("unknown project prefix %q%s", project, utils2.DidYouMean(project, utils2.StringMapKeys(r.resolution.externalterms)))
+	}
+	t := terms[tag]
+	if t == nil {
+		return nil, fmt.Errorf("unknown term %q in inventory %q%s", tag, project, utils2.DidYouMean(tag, utils2.StringMapKeys(terms)))
+	}
+	return t, nil
 }
 
 func (r *ResolutionContext) Writer() scanner.Writer {
@@ -0,0 +1,202 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/mandelsoft/mdgen/scanner"
+	"github.com/mandelsoft/mdgen/statements/termdef"
+)
+
+// SEARCH_INDEX is the name of the search index written into
+// the root of a generated tree.
+const SEARCH_INDEX = "mdgen-search.json"
+
+// SearchIndex describes the content of a generated tree for a
+// client-side full-text search. Links are relative to the root
+// of the generated tree.
+type SearchIndex struct {
+	Sections []SearchSection `json:"sections"`
+	Terms    []SearchTerm    `json:"terms,omitempty"`
+}
+
+// SearchSection describes a section with the plain text of its
+// content. The content of sub sections is not included.
+type SearchSection struct {
+	Label   string `json:"label,omitempty"`
+	Title   string `json:"title"`
+	Link    string `json:"link"`
+	Content string `json:"content,omitempty"`
+}
+
+// SearchTerm describes a globally defined term with the plain
+// text of its glossary description.
+type SearchTerm struct {
+	Tag      string `json:"tag"`
+	Singular string `json:"singular"`
+	Plural   string `json:"plural"`
+	Link     string `json:"link"`
+	Content  string `json:"content,omitempty"`
+}
+
+// SetSearchIndex enables the generation of a search index
+// (SEARCH_INDEX) into the root of the generated tree.
+func (t *tree) SetSearchIndex(b bool) {
+	t.search = b
+}
+
+// capture keeps a copy of the markdown generated for a
+// target document, if a search index is requested.
+func (t *tree) capture(refpath string, w io.WriteCloser) io.WriteCloser {
+	if !t.search {
+		return w
+	}
+	buf := &bytes.Buffer{}
+	t.emitted[refpath] = buf
+	return &captureWriter{w, io.MultiWriter(w, buf)}
+}
+
+type captureWriter struct {
+	io.Closer
+	w io.Writer
+}
+
+func (c *captureWriter) Write(p []byte) (int, error) {
+	return c.w.Write(p)
+}
+
+// SearchIndex provides the search index for the tree
+// generated by the last call to Emit.
+func (t *tree) SearchIndex() (*SearchIndex, error) {
+	res := t.resolution
+	index := &SearchIndex{}
+
+	sections := map[string][]NavEntry{}
+	var order []string
+	for _, e := range t.Navigation() {
+		if sections[e.RefPath] == nil {
+			order = append(order, e.RefPath)
+		}
+		sections[e.RefPath] = append(sections[e.RefPath], e)
+	}
+	for _, rp := range order {
+		buf := t.emitted[rp]
+		if buf == nil {
+			continue
+		}
+		for _, s := range splitSections(buf.String(), sections[rp]) {
+			s.Link = res.rootDocumentLink(rp, s.Link)
+			index.Sections = append(index.Sections, s)
+		}
+	}
+
+	for _, c := range res.GetGlobalTags(termdef.GT_TERM) {
+		nctx := c.(*termdef.TermDefNodeContext)
+		term := SearchTerm{
+			Tag:      nctx.Term().Tag(),
+			Singular: nctx.Term().Singular(),
+			Plural:   nctx.Term().Plural(),
+		}
+		if r := res.refindex[nctx.GetLink()]; r != nil {
+			term.Link = res.rootLink(r)
+		}
+		if gctx := nctx.GetGlossaryContext(); gctx != nil {
+			buf := scanner.NewBufferContext(gctx)
+			err := nctx.GetNodeSequence().Emit(buf)
+			if err != nil {
+				return nil, err
+			}
+			term.Content = searchText(buf.String())
+		}
+		index.Terms = append(index.Terms, term)
+	}
+	sort.Slice(index.Terms, func(i, j int) bool { return index.Terms[i].Tag < index.Terms[j].Tag })
+	return index, nil
+}
+
+// splitSections splits the markdown of a generated document into
+// the content of its sections. The headings of the sections are
+// expected in the order of the given navigation entries, other
+// headings are kept as content. The link of the resulting entries
+// is the anchor of the section.
+func splitSections(md string, entries []NavEntry) []SearchSection {
+	var result []SearchSection
+	var content strings.Builder
+	cur := -1
+
+	flush := func() {
+		if cur >= 0 {
+			result[cur].Content = searchText(content.String())
+		}
+		content.Reset()
+	}
+
+	fence := ""
+	for _, line := range strings.SplitAfter(md, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		} else if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+		} else if len(result) < len(entries) && isHeading(trimmed) {
+			e := entries[len(result)]
+			heading := e.Title
+			if e.Label != "" {
+				heading = e.Label + " " + heading
+			}
+			if plainText(strings.TrimLeft(trimmed, "#")) == plainText(heading) {
+				flush()
+				cur = len(result)
+				result = append(result, SearchSection{
+					Label: e.Label,
+					Title: plainText(e.Title),
+					Link:  e.Anchor,
+				})
+				continue
+			}
+		}
+		content.WriteString(line)
+	}
+	flush()
+	return result
+}
+
+func isHeading(line string) bool {
+	n := len(line) - len(strings.TrimLeft(line, "#"))
+	return n > 0 && n <= 6 && (len(line) == n || line[n] == ' ')
+}
+
+// searchText provides the plain text of some markdown
+// with normalized white space.
+func searchText(md string) string {
+	return strings.Join(strings.Fields(plainText(md)), " ")
+}
+
+func (t *tree) emitSearchIndex(tw TreeWriter) error {
+	fw, ok := tw.(FileWriter)
+	if !ok {
+		return fmt.Errorf("tree writer does not support a search index")
+	}
+	index, err := t.SearchIndex()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	t.log.Infof("writing %s", SEARCH_INDEX)
+	return fw.File(SEARCH_INDEX, append(data, '\n'))
+}
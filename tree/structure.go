@@ -0,0 +1,120 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mandelsoft/mdgen/utils"
+)
+
+// PrintStructure prints the document structure of a resolved tree.
+// Every root document is printed together with the hierarchy of its
+// structural sub documents.
+func (t *tree) PrintStructure(w io.Writer) {
+	if t.resolution == nil {
+		return
+	}
+	for _, n := range utils.StringMapKeys(t.resolution.documents) {
+		di := t.resolution.documents[n]
+		if di.rootinfo != nil && di.IsRoot() {
+			printStructure(w, di, "")
+		}
+	}
+}
+
+func printStructure(w io.Writer, di *DocumentInfo, gap string) {
+	info := ""
+	if l := di.Label(); l != nil && l.Name() != "" {
+		info = " " + l.Name()
+	}
+	if t := di.Title(); t != nil && *t != "" {
+		info += fmt.Sprintf(" %q", *t)
+	}
+	if di.document.IsTemplate() {
+		info += " (template)"
+	}
+	fmt.Fprintf(w, "%s%s [%s]%s\n", gap, di.GetRefPath(), di.Source(), info)
+	for _, ref := range di.context.docrefs.order {
+		printStructure(w, ref.docinfo, gap+"  ")
+	}
+}
+
+// DocumentNode describes a generated document in the document
+// structure of a resolved tree together with its structural sub documents.
+// The path is the path of the generated document relative to the
+// target folder.
+type DocumentNode struct {
+	Label    string
+	Title    string
+	Path     string
+	Children []DocumentNode
+}
+
+// DocumentStructure provides the document structure of a resolved tree.
+// It contains an entry for every root document with the hierarchy of its
+// structural sub documents in the order they are referenced.
+// Templates are omitted.
+func (t *tree) DocumentStructure() []DocumentNode {
+	if t.resolution == nil {
+		return nil
+	}
+	var result []DocumentNode
+	for _, n := range utils.StringMapKeys(t.resolution.documents) {
+		di := t.resolution.documents[n]
+		if di.rootinfo != nil && di.IsRoot() && !di.document.IsTemplate() {
+			result = append(result, documentNode(di))
+		}
+	}
+	return result
+}
+
+func documentNode(di *DocumentInfo) DocumentNode {
+	n := DocumentNode{
+		Title: plainText(*di.Title()),
+		Path:  strings.TrimPrefix(di.GetTargetRefPath(), "/") + DEFAULT_EXTENSION,
+	}
+	if l := di.Label(); l != nil {
+		n.Label = l.Name()
+	}
+	if n.Title == "" {
+		n.Title = path.Base(di.GetTargetRefPath())
+	}
+	for _, ref := range di.context.docrefs.order {
+		if ref.docinfo != nil && !ref.docinfo.document.IsTemplate() {
+			n.Children = append(n.Children, documentNode(ref.docinfo))
+		}
+	}
+	return n
+}
+
+// Reference describes an entry of the reference index of a resolved
+// tree. A link is resolved to an anchor in the document with the given
+// ref path.
+type Reference struct {
+	Link    string
+	RefPath string
+	Anchor  string
+}
+
+// References provides the reference index of a resolved tree
+// ordered by the link.
+func (t *tree) References() []Reference {
+	if t.resolution == nil {
+		return nil
+	}
+	var result []Reference
+	for l, r := range t.resolution.refindex {
+		result = append(result, Reference{Link: l.String(), RefPath: r.GetRefPath(), Anchor: r.Anchor()})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Link < result[j].Link })
+	return result
+}
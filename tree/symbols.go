@@ -0,0 +1,200 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mandelsoft/mdgen/scanner"
+	"github.com/mandelsoft/mdgen/statements/termdef"
+	utils "github.com/mandelsoft/mdgen/utils"
+)
+
+// Symbol describes the definition of an element referred to by
+// the tag of a statement. The description is a short markdown text
+// describing the element, for example the glossary text of a term.
+type Symbol struct {
+	Location    scanner.Location
+	Description string
+}
+
+// linkStatements are the statements using a link to a referencable element.
+var linkStatements = map[string]bool{
+	"link":       true,
+	"ref":        true,
+	"sectionref": true,
+	"title":      true,
+	"label":      true,
+	"toc":        true,
+}
+
+// documentForSource provides the document info for the source
+// document with the given file path.
+func (t *tree) documentForSource(source string) *DocumentInfo {
+	if t.resolution == nil {
+		return nil
+	}
+	source = path.Clean(source)
+	for _, di := range t.resolution.documents {
+		if path.Clean(di.Source()) == source {
+			return di
+		}
+	}
+	return nil
+}
+
+// Candidates provides the names usable as tag for a statement
+// used in the source document with the given file path at the given
+// (1-based) line and column. For values, the parameters of the blocks
+// enclosing this position are provided, also.
+func (t *tree) Candidates(source, statement string, line, column int) []string {
+	di := t.documentForSource(source)
+	if di == nil {
+		return nil
+	}
+	var result []string
+	switch {
+	case linkStatements[statement]:
+		result = di.context.ReferencableCandidates()
+	case statement == "term":
+		result = di.context.TagCandidates(termdef.GT_TERM)
+	case statement == "blockref":
+		result = di.context.BlockCandidates()
+	case statement == "value":
+		result = append(di.context.ValueCandidates(), blockParameters(di.document, line, column)...)
+	}
+	return utils.SortedMapKeys(utils.Set[string]{}.Add(result...))
+}
+
+// blockParameters provides the parameters of the blocks found in the
+// given inventory enclosing the given position. Unfinished blocks
+// extend up to the end of the document.
+func blockParameters(inv scanner.Inventory, line, column int) []string {
+	var result []string
+	for id := range inv.GetBlockTags() {
+		b := inv.GetBlock(id)
+		if !before(b.Location(), line, column) {
+			continue
+		}
+		if e, ok := b.(interface{ EndLocation() *scanner.Location }); ok && e.EndLocation() != nil && before(*e.EndLocation(), line, column) {
+			continue
+		}
+		result = append(result, b.GetParameterNames()...)
+		result = append(result, blockParameters(b.Inventory(), line, column)...)
+	}
+	return result
+}
+
+// before checks whether a location is found before the given position.
+func before(l scanner.Location, line, column int) bool {
+	return l.Line() < line || (l.Line() == line && l.Column() < column)
+}
+
+// Definition looks up the element referred to by a statement with the
+// given tag used in the source document with the given file path.
+// If the element cannot be resolved, nil is returned.
+func (t *tree) Definition(source, statement, tag string) *Symbol {
+	di := t.documentForSource(source)
+	if di == nil {
+		return nil
+	}
+	switch {
+	case linkStatements[statement]:
+		l, err := utils.ParseAbsoluteLink(strings.TrimLeft(tag, "*^"), di.GetRefPath(), false)
+		if err != nil || l.IsExternal() {
+			return nil
+		}
+		ri := di.context.LookupReferencable(l)
+		if ri == nil {
+			return nil
+		}
+		loc := refLocation(ri)
+		if loc == nil {
+			return nil
+		}
+		return &Symbol{Location: *loc, Description: refDescription(ri)}
+	case statement == "term":
+		ref := termdef.MapTermTag(strings.TrimLeft(tag, "!#"))
+		if ref.Project() != "" {
+			return nil
+		}
+		nctx, ok := di.context.LookupTag(termdef.GT_TERM, ref.Tag()).(*termdef.TermDefNodeContext)
+		if !ok {
+			return nil
+		}
+		return &Symbol{Location: nctx.Location(), Description: termDescription(nctx)}
+	case statement == "blockref":
+		if i := strings.Index(tag, ":"); i >= 0 {
+			tag = tag[i+1:]
+		}
+		l, err := utils.ParseLink(tag, true)
+		if err == nil {
+			l, err = l.Abs(di.GetRefPath(), false)
+		}
+		if err != nil {
+			return nil
+		}
+		b, _ := di.context.LookupBlock(l)
+		if b == nil {
+			return nil
+		}
+		desc := fmt.Sprintf("text module `%s`", b.Tag())
+		if params := b.GetParameterNames(); len(params) > 0 {
+			sort.Strings(params)
+			desc += "\n\nparameters: " + strings.Join(params, ", ")
+		}
+		return &Symbol{Location: b.Location(), Description: desc}
+	}
+	return nil
+}
+
+func refLocation(ri scanner.RefInfo) *scanner.Location {
+	if rr, ok := ri.(*resolvedRef); ok {
+		ri = rr.info
+	}
+	var loc scanner.Location
+	switch i := ri.(type) {
+	case *DocumentInfo:
+		loc = i.document.Location()
+	case scanner.Located:
+		loc = i.Location()
+	default:
+		return nil
+	}
+	return &loc
+}
+
+func refDescription(ri scanner.RefInfo) string {
+	desc := ""
+	if l := ri.Label(); l != nil && l.Name() != "" {
+		desc = l.Type() + " " + l.Name()
+	}
+	if t := ri.Title(); t != nil && *t != "" {
+		if desc != "" {
+			desc += ": "
+		}
+		desc += *t
+	}
+	if desc == "" {
+		desc = "document " + ri.GetRefPath()
+	}
+	return desc
+}
+
+func termDescription(nctx *termdef.TermDefNodeContext) string {
+	desc := fmt.Sprintf("**%s** (plural: %s)", nctx.Term().Singular(), nctx.Term().Plural())
+	if gctx := nctx.GetGlossaryContext(); gctx != nil {
+		buf := scanner.NewBufferContext(gctx)
+		if nctx.GetNodeSequence().Emit(buf) == nil {
+			desc += "\n\n" + strings.TrimSpace(buf.String())
+		}
+	}
+	return desc
+}
@@ -7,6 +7,8 @@
 package tree
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"path"
 	"strings"
@@ -14,7 +16,10 @@ import (
 	"github.com/mandelsoft/vfs/pkg/osfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 
+	"github.com/mandelsoft/mdgen/diagnostics"
 	"github.com/mandelsoft/mdgen/labels"
+	"github.com/mandelsoft/mdgen/logging"
+	"github.com/mandelsoft/mdgen/render"
 	"github.com/mandelsoft/mdgen/scanner"
 	"github.com/mandelsoft/mdgen/statements/section"
 	utils "github.com/mandelsoft/mdgen/utils"
@@ -26,23 +31,65 @@ type tree struct {
 	documents  map[string]scanner.Document
 	resolution *Resolution
 
-	path string
-	copy bool
-	fs   vfs.FileSystem
+	path        string
+	copy        bool
+	single      bool
+	frontmatter bool
+	inventory   bool
+	search      bool
+	imported    map[string]*importedInventory
+	emitted     map[string]*bytes.Buffer
+	fs          vfs.FileSystem
+	log         logging.Logger
+
+	defaults scanner.LabelRules
+	values   map[string]string
+	renderer render.Renderer
 }
 
-func NewTree(path string, fs vfs.FileSystem) Tree {
+func NewTree(path string, fs vfs.FileSystem, log ...logging.Logger) Tree {
 	return &tree{
 		path:      path,
 		fs:        fs,
+		log:       utils.OptionalDefaulted(logging.Default(), log...),
+		renderer:  render.Default(),
 		documents: map[string]scanner.Document{},
 	}
 }
 
+func (t *tree) Logger() logging.Logger {
+	return t.log
+}
+
 func (t *tree) SetCopyMode(b bool) {
 	t.copy = b
 }
 
+// SetSingleFile enables the single file mode. Instead of a target
+// document per source document, one target document is generated
+// for every root document. It contains the root document followed
+// by its structural sub documents in structural order. Links between
+// documents are rewritten to anchors in the generated document.
+func (t *tree) SetSingleFile(b bool) {
+	t.single = b
+}
+
+// SetNumberRangeDefaults sets the number range rules used for
+// root documents not declaring a rule for a number range.
+func (t *tree) SetNumberRangeDefaults(rules scanner.LabelRules) {
+	t.defaults = rules
+}
+
+// SetRenderer sets the renderer used to emit the documents.
+func (t *tree) SetRenderer(r render.Renderer) {
+	t.renderer = r
+}
+
+// SetValues sets values predefined for all documents.
+func (t *tree) SetValues(values map[string]string) {
+	t.values = values
+}
+
 func (t *tree) Print(gap string) {
 	ngap := gap + "  "
 	fmt.Printf("%sTREE:\n", gap)
@@ -57,47 +104,124 @@ func (t *tree) GetDocument(refpath string) *DocumentInfo {
 	return t.resolution.documents[refpath]
 }
 
+// ScanOptions describes the options for scanning a source tree.
+// Include and Exclude are path patterns (see utils.MatchPath)
+// for source files relative to the source folder. If include patterns
+// are given, only matching files are used.
+type ScanOptions struct {
+	Logger  logging.Logger
+	Include []string
+	Exclude []string
+}
+
+func (o *ScanOptions) accept(p string) bool {
+	found := len(o.Include) == 0
+	for _, i := range o.Include {
+		found = found || utils.MatchPath(i, p)
+	}
+	if !found {
+		return false
+	}
+	for _, e := range o.Exclude {
+		if utils.MatchPath(e, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// ForFolder scans a source folder (or single source file) using
+// the default logger.
 func ForFolder(path string, fss ...vfs.FileSystem) (Tree, error) {
+	return ForFolderWithLogger(path, logging.Default(), fss...)
+}
+
+// ForFolderWithLogger scans a source folder (or single source file).
+// All progress messages of the processing of the tree are reported
+// to the given logger.
+func ForFolderWithLogger(path string, log logging.Logger, fss ...vfs.FileSystem) (Tree, error) {
+	return ForFolderWithOptions(path, ScanOptions{Logger: log}, fss...)
+}
+
+// ForFolderWithOptions scans a source folder (or single source file)
+// using the given scan options. The include and exclude patterns
+// are not applied to a single source file.
+func ForFolderWithOptions(path string, opts ScanOptions, fss ...vfs.FileSystem) (Tree, error) {
 	fs := utils.OptionalDefaulted(osfs.New(), fss...)
-	tr := NewTree(path, fs)
+	tr := NewTree(path, fs, utils.OptionalDefaulted(logging.Default(), opts.Logger))
 
 	var err error
 
 	if ok, nerr := vfs.IsFile(fs, path); nerr == nil && ok {
 		err = scanFile(tr, path, fs, "/")
 	} else {
-		err = scanDir(tr, path, fs, "/")
+		err = scanDir(tr, path, fs, "/", &opts)
 
 	}
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", path, err)
+		return nil, diagnostics.WithCode(err, diagnostics.CODE_SYNTAX)
 	}
 	return tr, nil
 }
 
-func scanDir(tr Tree, p string, fs vfs.FileSystem, refpath string) error {
-	fmt.Printf("%s: scanning %s\n", refpath, p)
+// SourceFiles provides the paths of the source files found in a
+// source folder using the given scan options. For a single source
+// file the file itself is returned.
+func SourceFiles(path string, opts ScanOptions, fss ...vfs.FileSystem) ([]string, error) {
+	fs := utils.OptionalDefaulted(osfs.New(), fss...)
+	if ok, err := vfs.IsFile(fs, path); err == nil && ok {
+		return []string{path}, nil
+	}
+	return sourceFiles(path, fs, "/", &opts)
+}
+
+func sourceFiles(p string, fs vfs.FileSystem, refpath string, opts *ScanOptions) ([]string, error) {
+	list, err := vfs.ReadDir(fs, p)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, f := range list {
+		if f.IsDir() {
+			files, err := sourceFiles(path.Join(p, f.Name()), fs, path.Join(refpath, f.Name()), opts)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, files...)
+		} else if strings.HasSuffix(f.Name(), ".mdg") && opts.accept(path.Join(refpath, f.Name())[1:]) {
+			result = append(result, path.Join(p, f.Name()))
+		}
+	}
+	return result, nil
+}
+
+func scanDir(tr Tree, p string, fs vfs.FileSystem, refpath string, opts *ScanOptions) error {
+	tr.log.Infof("%s: scanning %s", refpath, p)
 	list, err := vfs.ReadDir(fs, p)
 	if err != nil {
 		return err
 	}
+	var diags diagnostics.Diagnostics
 	for _, f := range list {
 		if f.IsDir() {
-			err = scanDir(tr, path.Join(p, f.Name()), fs, path.Join(refpath, f.Name()))
+			err = scanDir(tr, path.Join(p, f.Name()), fs, path.Join(refpath, f.Name()), opts)
 		} else {
 			if strings.HasSuffix(f.Name(), ".mdg") {
+				if !opts.accept(path.Join(refpath, f.Name())[1:]) {
+					tr.log.Debugf("%s: skipping %s", refpath, f.Name())
+					continue
+				}
 				err = scanFile(tr, path.Join(p, f.Name()), fs, path.Join(refpath, f.Name()[:len(f.Name())-4]))
 			}
 		}
-		if err != nil {
-			return err
-		}
+		// continue with the other files to report all syntax errors
+		diags.Add(err, diagnostics.CODE_SYNTAX)
 	}
-	return nil
+	return diags.Err()
 }
 
 func scanFile(tr Tree, p string, fs vfs.FileSystem, refpath string) error {
-	fmt.Printf("%s: reading %s\n", refpath, p)
+	tr.log.Infof("%s: reading %s", refpath, p)
 	file, err := fs.Open(p)
 	if err != nil {
 		return err
@@ -115,36 +239,47 @@ func scanFile(tr Tree, p string, fs vfs.FileSystem, refpath string) error {
 }
 
 func (t *tree) Resolve() error {
-	res, err := NewResolution(t.documents, t.path, t.fs, t.copy)
+	res, err := NewResolution(t.documents, t.path, t.fs, t.copy, t.log)
 	if err != nil {
 		return err
 	}
 	t.resolution = res
+	res.SetValues(t.values)
+	res.SetRenderer(t.renderer)
+	res.single = t.single
+	for _, prefix := range utils.StringMapKeys(t.imported) {
+		err = res.importInventory(prefix, t.imported[prefix])
+		if err != nil {
+			return err
+		}
+	}
 
-	fmt.Printf("resolve blocks...\n")
+	t.log.Infof("resolve blocks...")
 	err = t.ResolveBlocks(res)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("resolve structure...\n")
+	t.log.Infof("resolve structure...")
 	err = t.ResolveStructural(res)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("resolve number ranges...\n")
+	t.log.Infof("resolve number ranges...")
 	err = t.ResolveNumberRanges(res)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("reference list:\n")
-	for l, r := range res.refindex {
-		fmt.Printf("  %s: %s#%s\n", l, r.GetRefPath(), r.Anchor())
+	if t.log.Enabled(logging.DebugLevel) {
+		t.log.Debugf("reference list:")
+		for l, r := range res.refindex {
+			t.log.Debugf("  %s: %s#%s", l, r.GetRefPath(), r.Anchor())
+		}
 	}
 
-	fmt.Printf("resolve values...\n")
+	t.log.Infof("resolve values...")
 	err = t.ResolveValues(res)
 	if err != nil {
 		return err
@@ -153,44 +288,60 @@ func (t *tree) Resolve() error {
 	return nil
 }
 
+// Dependencies provides the files and folders used to generate the
+// documents besides the document sources, like included files.
+// They are registered by the last call to Resolve, even if it failed.
+func (t *tree) Dependencies() []string {
+	if t.resolution == nil {
+		return nil
+	}
+	return utils.SortedMapKeys(t.resolution.dependencies)
+}
+
 func (t *tree) ResolveBlocks(res *Resolution) error {
+	var list diagnostics.Diagnostics
 	for _, di := range res.documents {
 		di.document.RequestNumberRanges(di.context)
 		err := di.Walk(scanner.Resolve[scanner.Register](di.context))
-		if err != nil {
-			return err
-		}
+		list.Add(err, diagnostics.CODE_DEFINITION)
 	}
-	return nil
+	list.Sort()
+	return list.Err()
 }
 
 func (t *tree) ResolveStructural(res *Resolution) error {
+	var list diagnostics.Diagnostics
 	found := map[scanner.Document]*docref{}
 	for _, di := range res.documents {
+	links:
 		for l, ref := range di.context.docrefs.links {
 			var target scanner.Document
 			if l.IsTag() {
 				ri := res.refindex[l]
 				if ri == nil {
-					return fmt.Errorf("%s: structural tag reference %q cannot be resolved", ref.location, l)
+					list.Add(ref.location.Errorf("structural tag reference %q cannot be resolved", l), diagnostics.CODE_STRUCTURE)
+					continue
 				}
 				target = ri.Context().GetDocument()
 			} else {
 				target = t.documents[l.Path()]
 				if target == nil {
-					return fmt.Errorf("%s: structural document reference %q cannot be resolved", ref.location, l)
+					list.Add(ref.location.Errorf("structural document reference %q cannot be resolved", l), diagnostics.CODE_STRUCTURE)
+					continue
 				}
 			}
 			ti := res.documents[target.GetRefPath()]
 			if ti.structinfo != nil {
-				return fmt.Errorf("%s: duplicate structural usage of document %s: %s", ref.location, target.Source(), found[target].location)
+				list.Add(ref.location.Errorf("duplicate structural usage of document %s: %s", target.Source(), found[target].location), diagnostics.CODE_STRUCTURE)
+				continue
 			}
-			fmt.Printf("%s: found structural usage in %s\n", target.Source(), di.Source())
+			t.log.Debugf("%s: found structural usage in %s", target.Source(), di.Source())
 			sect := false
 			for _, n := range target.GetNodes() {
 				if _, ok := n.(section.Node); ok {
 					if sect {
-						return fmt.Errorf("%s: structural document %s may contain only one top level section\n", ref.location, target.Source())
+						list.Add(ref.location.Errorf("structural document %s may contain only one top level section", target.Source()), diagnostics.CODE_STRUCTURE)
+						continue links
 					}
 					sect = true
 				}
@@ -200,24 +351,28 @@ func (t *tree) ResolveStructural(res *Resolution) error {
 			found[target] = ref
 		}
 	}
-	return nil
+	list.Sort()
+	return list.Err()
 }
 
 func (t *tree) ResolveNumberRanges(res *Resolution) error {
+	var list diagnostics.Diagnostics
 	for _, di := range res.documents {
 		err := t.resolveDocumentOrder(res, di, utils.History{})
 		if err != nil {
-			return err
+			return diagnostics.WithCode(err, diagnostics.CODE_STRUCTURE)
 		}
 	}
 	for _, di := range res.documents {
 		if di.IsRoot() {
 			err := t.resolveNumberRanges(di)
-			if err != nil {
-				return err
-			}
+			list.Add(err, diagnostics.CODE_REFERENCES)
 		}
 	}
+	if len(list) > 0 {
+		list.Sort()
+		return list
+	}
 
 	for _, di := range res.documents {
 		if di.IsRoot() {
@@ -248,9 +403,9 @@ func (t *tree) resolveDocumentOrder(res *Resolution, di *DocumentInfo, hist util
 			return err
 		}
 		root = res.documents[master.docinfo.GetRefPath()].rootinfo
-		fmt.Printf("%s: sub structure for %s\n", di.Source(), root.docinfo.Source())
+		t.log.Debugf("%s: sub structure for %s", di.Source(), root.docinfo.Source())
 	} else {
-		fmt.Printf("%s: root document\n", di.Source())
+		t.log.Debugf("%s: root document", di.Source())
 		root = NewRootInfo(di)
 	}
 	di.rootinfo = root
@@ -276,18 +431,21 @@ func (t *tree) resolveLabels(di *DocumentInfo, nr *NumberRangeInfo, hist utils.H
 			return err
 		}
 	}
-	fmt.Printf("%s: generate %s labels\n", di.Source(), nr.Type())
-	nr.CreateLabels(nil)
+	t.log.Debugf("%s: generate %s labels", di.Source(), nr.Type())
+	nr.CreateLabels(nil, t.log)
 	return nil
 }
 
 func (t *tree) resolveNumberRanges(di *DocumentInfo) error {
 	root := di.rootinfo
-	fmt.Printf("%s: found numberranges: %s\n", di.Source(), strings.Join(utils.SortedMapKeys(di.context.numberranges), ", "))
+	t.log.Debugf("%s: found numberranges: %s", di.Source(), strings.Join(utils.SortedMapKeys(di.context.numberranges), ", "))
 	rules := di.document.GetLabelRules()
 	for typ := range di.context.numberranges {
 		outer := root.ranges[typ]
 		l := rules[typ]
+		if l == nil && outer == nil {
+			l = t.defaults[typ]
+		}
 		if outer == nil {
 			var r labels.Rule
 			master := ""
@@ -328,9 +486,9 @@ func (t *tree) resolveNumberRanges(di *DocumentInfo) error {
 					}
 					return l
 				}
-				fmt.Printf("%s: initialize number range %s with master %s: %s\n", di.Source(), typ, master, r.Format())
+				t.log.Debugf("%s: initialize number range %s with master %s: %s", di.Source(), typ, master, r.Format())
 			} else {
-				fmt.Printf("%s: initialize number range %s: %s\n", di.Source(), typ, r.Format())
+				t.log.Debugf("%s: initialize number range %s: %s", di.Source(), typ, r.Format())
 			}
 
 			var loc *scanner.Location
@@ -349,7 +507,7 @@ func (t *tree) resolveNumberRanges(di *DocumentInfo) error {
 					outer.SetRule(l.Separator, outer.GetRule().WithLevel(l.Level))
 				}
 			}
-			fmt.Printf("%s: found reused number range %s from root document\n", di.Source(), typ)
+			t.log.Debugf("%s: found reused number range %s from root document", di.Source(), typ)
 		}
 	}
 
@@ -359,7 +517,7 @@ func (t *tree) resolveNumberRanges(di *DocumentInfo) error {
 			return err
 		}
 	}
-	fmt.Printf("  resolve %s\n", di.GetRefPath())
+	t.log.Debugf("  resolve %s", di.GetRefPath())
 	err := di.Walk(scanner.Resolve[scanner.LabelResolver](di.context))
 	if err != nil {
 		return err
@@ -399,6 +557,7 @@ func (t *tree) ResolveValues(res *Resolution) error {
 	last := -1
 
 	for last != 0 {
+		var list diagnostics.Diagnostics
 		found = map[string][]unresolved{}
 		cnt := 0
 		for rp, di := range t.resolution.documents {
@@ -408,17 +567,22 @@ func (t *tree) ResolveValues(res *Resolution) error {
 			di.context.unresolved = nil
 			err := di.Walk(scanner.Resolve[scanner.ValueResolver](di.context))
 			if err != nil {
-				return err
+				list.Add(err, diagnostics.CODE_RESOLUTION)
+				continue
 			}
 			found[rp] = di.context.unresolved
 			cnt += len(di.context.unresolved)
 			if len(di.context.unresolved) > 0 {
-				fmt.Printf("%s: found %d unresolved nodes:\n", di.Source(), len(di.context.unresolved))
+				t.log.Debugf("%s: found %d unresolved nodes:", di.Source(), len(di.context.unresolved))
 				for _, u := range di.context.unresolved {
-					fmt.Printf("   %s: %s\n", u.Location(), u.err)
+					t.log.Debugf("   %s: %s", u.Location(), u.err)
 				}
 			}
 		}
+		if len(list) > 0 {
+			list.Sort()
+			return list
+		}
 		if last > 0 {
 			if cnt > last {
 				panic("oops: growing number of problems ")
@@ -430,21 +594,64 @@ func (t *tree) ResolveValues(res *Resolution) error {
 		last = cnt
 	}
 	if last > 0 {
-		msg := ""
-		for n, l := range found {
-			msg += fmt.Sprintf("%s: found %d unresolved nodes:\n", res.documents[n].Source(), len(l))
+		var list diagnostics.Diagnostics
+		for _, l := range found {
 			for _, u := range l {
-				msg += fmt.Sprintf("   %s: %s\n", u.Location(), u.err)
+				list.Add(unresolvedDiagnostic(u), diagnostics.CODE_UNRESOLVED)
 			}
 		}
-		return fmt.Errorf("%s", msg)
+		list.Sort()
+		return list
 	}
 	return nil
 }
 
+// unresolvedDiagnostic provides the diagnostic for an unresolved node.
+// If the resolution error already describes a more specific location
+// (the failing nested statement) it is used, otherwise the location
+// of the unresolved node.
+func unresolvedDiagnostic(u unresolved) error {
+	var d *diagnostics.Diagnostic
+	if errors.As(u.err, &d) {
+		return u.err
+	}
+	return u.Error(u.err)
+}
+
 func (t *tree) Emit(tw TreeWriter) error {
 	t.resolution.targetroot = tw.Root()
+	t.resolution.writer = tw
+	t.resolution.extension = documentExtension(tw)
+	t.resolution.links, _ = tw.(DocumentLinks)
+	t.emitted = map[string]*bytes.Buffer{}
+	t.resolution.emitted = map[string]utils.Set[string]{}
+
+	t.resolution.anchors = nil
+	if h, ok := t.resolution.renderer.(render.HeadingAnchors); ok {
+		anchors := newHeadingAnchors(t.resolution.renderer, h)
+		t.resolution.renderer, t.resolution.anchors = anchors, anchors
+		defer func() { t.resolution.renderer = anchors.Renderer }()
+	}
+
+	var err error
+	if t.single {
+		err = t.emitSingleFile(tw)
+	} else {
+		err = t.emitDocuments(tw)
+	}
+	if err == nil && t.resolution.anchors != nil {
+		err = t.resolution.anchors.Close()
+	}
+	if err == nil && t.inventory {
+		err = t.emitInventory(tw)
+	}
+	if err == nil && t.search {
+		err = t.emitSearchIndex(tw)
+	}
+	return err
+}
 
+func (t *tree) emitDocuments(tw TreeWriter) error {
 	for _, di := range t.resolution.documents {
 		if di.document.IsTemplate() {
 			continue
@@ -453,10 +660,12 @@ func (t *tree) Emit(tw TreeWriter) error {
 		if err != nil {
 			return err
 		}
+		w = t.capture(di.document.GetTargetRefPath(), w)
+		w = t.resolution.anchors.document(di.document.GetTargetRefPath(), w)
 		if di.document.GetTargetRefPath() == di.document.GetRefPath() {
-			fmt.Printf("writing %s\n", di.document.GetTargetRefPath())
+			t.log.Infof("writing %s", di.document.GetTargetRefPath())
 		} else {
-			fmt.Printf("writing %s[%s]\n", di.document.GetTargetRefPath(), di.document.GetRefPath())
+			t.log.Infof("writing %s[%s]", di.document.GetTargetRefPath(), di.document.GetRefPath())
 		}
 		/*
 			toc := scanner.DocTOCIds(di.context, scanner.SECTION_TYPE)
@@ -464,14 +673,51 @@ func (t *tree) Emit(tw TreeWriter) error {
 				fmt.Printf("%*s-%s\n", c.Level(), "", c.Id())
 			}
 		*/
-		err = di.Emit(scanner.NewWriter(w), target)
+		sw := scanner.NewWriter(w)
+		if t.frontmatter {
+			err = t.emitFrontMatter(sw, di)
+		}
+		if err == nil {
+			err = di.Emit(sw, target)
+		}
 		err2 := w.Close()
 		if err2 != nil {
 			return err2
 		}
+		if err != nil {
+			return diagnostics.WithCode(err, diagnostics.CODE_EMIT)
+		}
+	}
+	return nil
+}
+
+func (t *tree) emitSingleFile(tw TreeWriter) error {
+	for _, n := range utils.StringMapKeys(t.resolution.documents) {
+		di := t.resolution.documents[n]
+		if di.document.IsTemplate() || di.rootinfo == nil || !di.IsRoot() {
+			continue
+		}
+		w, target, err := tw.Document(di.document.GetTargetRefPath())
 		if err != nil {
 			return err
 		}
+		w = t.capture(di.document.GetTargetRefPath(), w)
+		w = t.resolution.anchors.document(di.document.GetTargetRefPath(), w)
+		t.log.Infof("writing %s[%s]", di.document.GetTargetRefPath(), di.document.GetRefPath())
+		sw := scanner.NewWriter(w)
+		if t.frontmatter {
+			err = t.emitFrontMatter(sw, di)
+		}
+		if err == nil {
+			err = di.Emit(sw, target)
+		}
+		err2 := w.Close()
+		if err2 != nil {
+			return err2
+		}
+		if err != nil {
+			return diagnostics.WithCode(err, diagnostics.CODE_EMIT)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,40 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree_test
+
+import (
+	"errors"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/mdgen/diagnostics"
+	"github.com/mandelsoft/mdgen/logging"
+	"github.com/mandelsoft/mdgen/tree"
+)
+
+var _ = Describe("tree", func() {
+	var fs vfs.FileSystem
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(fs.MkdirAll("/src", 0o755)).To(Succeed())
+	})
+
+	It("reports syntax errors as diagnostics", func() {
+		Expect(vfs.WriteFile(fs, "/src/doc.mdg", []byte("text\n{{section intro}}Intro\ntext\n"), 0o644)).To(Succeed())
+		_, err := tree.ForFolderWithLogger("/src", logging.Discard(), fs)
+		Expect(err).To(MatchError("/src/doc.mdg: line 2, column 1: unfinished section"))
+
+		var list diagnostics.Diagnostics
+		Expect(errors.As(err, &list)).To(BeTrue())
+		Expect(list).To(HaveLen(1))
+		Expect(list[0].Code).To(Equal(diagnostics.CODE_SYNTAX))
+	})
+})
@@ -7,26 +7,75 @@
 package tree
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"path"
+	"strings"
 
 	"github.com/mandelsoft/filepath/pkg/filepath"
 	"github.com/mandelsoft/vfs/pkg/osfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/mdgen/utils"
 )
 
 type TreeWriter interface {
 	Root() string
 	Document(refpath string) (io.WriteCloser, string, error)
+	// Resource provides a copy of a resource file at the given target path.
+	Resource(fs vfs.FileSystem, src string, target string) error
 	Close() error
 }
 
+// DEFAULT_EXTENSION is the file extension of generated markdown documents.
+const DEFAULT_EXTENSION = ".md"
+
+// DocumentExtension is an optional interface of a TreeWriter
+// generating documents with another file extension than DEFAULT_EXTENSION.
+// Links between generated documents use this extension.
+type DocumentExtension interface {
+	DocumentExtension() string
+}
+
+func documentExtension(tw TreeWriter) string {
+	if e, ok := tw.(DocumentExtension); ok {
+		return e.DocumentExtension()
+	}
+	return DEFAULT_EXTENSION
+}
+
+// DocumentLinks is an optional interface of a TreeWriter, which
+// does not use relative file paths for links between generated documents.
+// It provides the link used to refer to the document with the given
+// target ref path.
+type DocumentLinks interface {
+	DocumentLink(refpath string) string
+}
+
+// FileWriter is an optional interface of a TreeWriter accepting
+// additional generated files besides the documents and resources.
+// The name is a path relative to the target root.
+type FileWriter interface {
+	File(name string, data []byte) error
+}
+
+// FileTreeWriter writes the generated target tree into a folder of
+// a filesystem. Together with the generated files a manifest (see MANIFEST)
+// is written into the target root. It is used to prune generated files,
+// which are not generated anymore.
+type FileTreeWriter = *fileTreeWriter
+
 type fileTreeWriter struct {
-	root string
-	fs   vfs.FileSystem
+	root      string
+	absroot   string
+	fs        vfs.FileSystem
+	prune     bool
+	generated utils.Set[string]
+	pruned    []string
 }
 
-func NewFileTreeWriter(path string, fss ...vfs.FileSystem) (TreeWriter, error) {
+func NewFileTreeWriter(path string, fss ...vfs.FileSystem) (FileTreeWriter, error) {
 	fs := osfs.New()
 	for _, f := range fss {
 		if f != nil {
@@ -39,26 +88,150 @@ func NewFileTreeWriter(path string, fss ...vfs.FileSystem) (TreeWriter, error) {
 	if err != nil {
 		return nil, fmt.Errorf("cannot target dir %s: %w", path, err)
 	}
+	abs, err := vfs.Canonical(fs, path, true)
+	if err != nil {
+		return nil, err
+	}
 	return &fileTreeWriter{
-		root: path,
-		fs:   fs,
+		root:      path,
+		absroot:   abs,
+		fs:        fs,
+		generated: utils.Set[string]{},
 	}, nil
 }
 
+// SetPrune enables the deletion of files listed in the manifest
+// of a previous run, which are not generated anymore.
+func (w *fileTreeWriter) SetPrune(b bool) {
+	w.prune = b
+}
+
+// Pruned returns the files deleted by Close.
+func (w *fileTreeWriter) Pruned() []string {
+	return w.pruned
+}
+
 func (w *fileTreeWriter) Root() string {
 	return w.root
 }
 
 func (w *fileTreeWriter) Document(refpath string) (io.WriteCloser, string, error) {
-	path := w.root + refpath + ".md"
+	path := w.root + refpath + DEFAULT_EXTENSION
 	err := w.fs.MkdirAll(filepath.Dir(path), 0755)
 	if err != nil {
 		return nil, path, fmt.Errorf("cannot create dir %s: %w", filepath.Dir(path), err)
 	}
-	f, err := w.fs.OpenFile(path, vfs.O_WRONLY|vfs.O_TRUNC|vfs.O_CREATE, 0644)
-	return f, path, err
+	w.generated.Add(strings.TrimPrefix(refpath, "/") + DEFAULT_EXTENSION)
+	return &documentWriter{fs: w.fs, path: path}, path, nil
 }
 
+func (w *fileTreeWriter) Resource(fs vfs.FileSystem, src string, target string) error {
+	data, err := vfs.ReadFile(fs, src)
+	if err != nil {
+		return err
+	}
+	err = w.fs.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return fmt.Errorf("cannot create dir %s: %w", filepath.Dir(target), err)
+	}
+	abs, err := vfs.Canonical(w.fs, target, false)
+	if err != nil {
+		return err
+	}
+	rel, err := vfs.Rel(w.fs, w.absroot, abs)
+	if err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+		w.generated.Add(rel)
+	}
+	old, err := vfs.ReadFile(w.fs, target)
+	if err == nil && bytes.Equal(old, data) {
+		return nil
+	}
+	return vfs.WriteFile(w.fs, target, data, 0644)
+}
+
+func (w *fileTreeWriter) File(name string, data []byte) error {
+	p := path.Join(w.root, name)
+	err := w.fs.MkdirAll(path.Dir(p), 0755)
+	if err != nil {
+		return fmt.Errorf("cannot create dir %s: %w", path.Dir(p), err)
+	}
+	w.generated.Add(path.Clean(name))
+	old, err := vfs.ReadFile(w.fs, p)
+	if err == nil && bytes.Equal(old, data) {
+		return nil
+	}
+	return vfs.WriteFile(w.fs, p, data, 0644)
+}
+
+// Close finishes the generation of the target tree by writing
+// the manifest. Files of the old manifest not generated anymore are deleted
+// if pruning is enabled, otherwise they are kept in the manifest.
+// Close must only be called after a successful generation of the complete tree.
 func (w *fileTreeWriter) Close() error {
-	return nil
+	old, err := ReadManifest(w.fs, w.root)
+	if err != nil {
+		return fmt.Errorf("cannot read manifest: %w", err)
+	}
+	entries := utils.Set[string]{}
+	for e := range w.generated {
+		entries.Add(e)
+	}
+	for _, e := range old {
+		if entries.Has(e) {
+			continue
+		}
+		p := path.Join(w.root, e)
+		ok, err := vfs.FileExists(w.fs, p)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if !w.prune {
+			entries.Add(e)
+			continue
+		}
+		err = w.fs.Remove(p)
+		if err != nil {
+			return fmt.Errorf("cannot prune %s: %w", p, err)
+		}
+		w.pruned = append(w.pruned, p)
+		w.removeEmptyDirs(path.Dir(e))
+	}
+	return writeManifest(w.fs, w.root, entries)
+}
+
+// removeEmptyDirs removes a folder of the target tree and its parent folders
+// as long as they are empty.
+func (w *fileTreeWriter) removeEmptyDirs(dir string) {
+	for dir != "." && dir != "/" && dir != "" {
+		p := path.Join(w.root, dir)
+		list, err := vfs.ReadDir(w.fs, p)
+		if err != nil || len(list) > 0 {
+			return
+		}
+		if w.fs.Remove(p) != nil {
+			return
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// documentWriter buffers the generated content and writes
+// the target file only if its content has changed.
+type documentWriter struct {
+	bytes.Buffer
+	fs   vfs.FileSystem
+	path string
+}
+
+func (w *documentWriter) Close() error {
+	old, err := vfs.ReadFile(w.fs, w.path)
+	if err == nil && bytes.Equal(old, w.Bytes()) {
+		return nil
+	}
+	return vfs.WriteFile(w.fs, w.path, w.Bytes(), 0644)
 }
@@ -0,0 +1,205 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/mdgen/utils"
+)
+
+const DefaultWatchInterval = 500 * time.Millisecond
+
+type fileState struct {
+	modtime time.Time
+	size    int64
+}
+
+type snapshot map[string]fileState
+
+func (s snapshot) changes(old snapshot) []string {
+	var changed []string
+	for p, n := range s {
+		if o, ok := old[p]; !ok || o != n {
+			changed = append(changed, p)
+		}
+	}
+	for p := range old {
+		if _, ok := s[p]; !ok {
+			changed = append(changed, p)
+		}
+	}
+	return changed
+}
+
+// Watcher observes a source folder and reports changes
+// of any contained file (mdg sources, included files or resources).
+// Additionally, dependencies outside the source folder
+// (see SetDependencies) are observed.
+type Watcher = *watcher
+
+type watcher struct {
+	fs       vfs.FileSystem
+	path     string
+	excludes []string
+	interval time.Duration
+	last     snapshot
+
+	deps     []string
+	lastdeps snapshot
+}
+
+// NewWatcher creates a watcher for the given source folder. The given
+// exclude paths (for example the target folder) are ignored.
+func NewWatcher(path string, excludes []string, fss ...vfs.FileSystem) (Watcher, error) {
+	fs := utils.OptionalDefaulted(osfs.New(), fss...)
+	w := &watcher{
+		fs:       fs,
+		path:     path,
+		interval: DefaultWatchInterval,
+	}
+	for _, e := range excludes {
+		c, err := vfs.Canonical(fs, e, false)
+		if err != nil {
+			return nil, err
+		}
+		w.excludes = append(w.excludes, c)
+	}
+	s, err := w.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	w.last = s
+	return w, nil
+}
+
+func (w *watcher) SetInterval(d time.Duration) {
+	w.interval = d
+}
+
+// SetDependencies sets the additional files or folders observed
+// by the watcher, for example included files outside the source folder.
+// Missing paths are observed for their creation.
+func (w *watcher) SetDependencies(paths []string) error {
+	s, err := w.dependencies(paths)
+	if err != nil {
+		return err
+	}
+	w.deps = paths
+	w.lastdeps = s
+	return nil
+}
+
+// Changed checks whether the watched folder or the dependencies
+// have been changed since the last call.
+func (w *watcher) Changed() ([]string, error) {
+	s, err := w.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	d, err := w.dependencies(w.deps)
+	if err != nil {
+		return nil, err
+	}
+	changed := append(s.changes(w.last), d.changes(w.lastdeps)...)
+	w.last = s
+	w.lastdeps = d
+	return changed, nil
+}
+
+// Wait blocks until a change is detected or the stop channel is closed.
+func (w *watcher) Wait(stop <-chan struct{}) ([]string, error) {
+	for {
+		select {
+		case <-stop:
+			return nil, nil
+		case <-time.After(w.interval):
+		}
+		changed, err := w.Changed()
+		if err != nil || len(changed) > 0 {
+			return changed, err
+		}
+	}
+}
+
+func (w *watcher) snapshot() (snapshot, error) {
+	s := snapshot{}
+	fi, err := w.fs.Stat(w.path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		s[w.path] = fileState{fi.ModTime(), fi.Size()}
+		return s, nil
+	}
+	return s, w.scan(s, w.path)
+}
+
+func (w *watcher) dependencies(paths []string) (snapshot, error) {
+	s := snapshot{}
+	for _, p := range paths {
+		fi, err := w.fs.Stat(p)
+		if err != nil {
+			if vfs.IsErrNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if !fi.IsDir() {
+			s[p] = fileState{fi.ModTime(), fi.Size()}
+			continue
+		}
+		err = w.scan(s, p)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (w *watcher) scan(s snapshot, p string) error {
+	if w.excluded(p) {
+		return nil
+	}
+	list, err := vfs.ReadDir(w.fs, p)
+	if err != nil {
+		return err
+	}
+	for _, f := range list {
+		n := path.Join(p, f.Name())
+		if f.IsDir() {
+			err = w.scan(s, n)
+			if err != nil {
+				return err
+			}
+		} else {
+			s[n] = fileState{f.ModTime(), f.Size()}
+		}
+	}
+	return nil
+}
+
+func (w *watcher) excluded(p string) bool {
+	if len(w.excludes) == 0 {
+		return false
+	}
+	c, err := vfs.Canonical(w.fs, p, false)
+	if err != nil {
+		return false
+	}
+	for _, e := range w.excludes {
+		if c == e || strings.HasPrefix(c, e+"/") {
+			return true
+		}
+	}
+	return false
+}
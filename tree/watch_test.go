@@ -0,0 +1,124 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/mdgen/logging"
+	_ "github.com/mandelsoft/mdgen/statements"
+	"github.com/mandelsoft/mdgen/tree"
+)
+
+const interval = 10 * time.Millisecond
+
+// wait runs the watch loop until a change is detected.
+func wait(w tree.Watcher) []string {
+	stop := make(chan struct{})
+	timer := time.AfterFunc(5*time.Second, func() { close(stop) })
+	defer timer.Stop()
+	changed, err := w.Wait(stop)
+	ExpectWithOffset(1, err).To(Succeed())
+	return changed
+}
+
+var _ = Describe("watcher", func() {
+	var fs vfs.FileSystem
+	var w tree.Watcher
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(fs.MkdirAll("/src/sub", 0o755)).To(Succeed())
+		Expect(fs.MkdirAll("/src/doc", 0o755)).To(Succeed())
+		Expect(fs.MkdirAll("/data", 0o755)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/src/sub/doc.mdg", []byte("text"), 0o644)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/data/file", []byte("data"), 0o644)).To(Succeed())
+
+		var err error
+		w, err = tree.NewWatcher("/src", []string{"/src/doc"}, fs)
+		Expect(err).To(Succeed())
+		w.SetInterval(interval)
+	})
+
+	It("reports no changes", func() {
+		Expect(w.Changed()).To(BeEmpty())
+	})
+
+	It("detects source changes", func() {
+		go func() {
+			time.Sleep(3 * interval)
+			vfs.WriteFile(fs, "/src/sub/doc.mdg", []byte("changed text"), 0o644)
+		}()
+		Expect(wait(w)).To(ConsistOf("/src/sub/doc.mdg"))
+		Expect(w.Changed()).To(BeEmpty())
+	})
+
+	It("detects created and deleted sources", func() {
+		Expect(vfs.WriteFile(fs, "/src/new.mdg", []byte("text"), 0o644)).To(Succeed())
+		Expect(fs.Remove("/src/sub/doc.mdg")).To(Succeed())
+		Expect(wait(w)).To(ConsistOf("/src/new.mdg", "/src/sub/doc.mdg"))
+	})
+
+	It("ignores excluded folders", func() {
+		Expect(vfs.WriteFile(fs, "/src/doc/doc.md", []byte("text"), 0o644)).To(Succeed())
+		Expect(w.Changed()).To(BeEmpty())
+	})
+
+	It("stops waiting", func() {
+		stop := make(chan struct{})
+		close(stop)
+		Expect(w.Wait(stop)).To(BeEmpty())
+	})
+
+	It("detects dependency changes", func() {
+		Expect(w.SetDependencies([]string{"/data/file", "/data/missing", "/data"})).To(Succeed())
+		Expect(w.Changed()).To(BeEmpty())
+
+		go func() {
+			time.Sleep(3 * interval)
+			vfs.WriteFile(fs, "/data/file", []byte("changed data"), 0o644)
+		}()
+		Expect(wait(w)).To(ConsistOf("/data/file"))
+
+		Expect(vfs.WriteFile(fs, "/data/missing", []byte("data"), 0o644)).To(Succeed())
+		Expect(wait(w)).To(ConsistOf("/data/missing"))
+	})
+
+	It("forgets obsolete dependencies", func() {
+		Expect(w.SetDependencies([]string{"/data/file"})).To(Succeed())
+		Expect(w.SetDependencies(nil)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/data/file", []byte("changed data"), 0o644)).To(Succeed())
+		Expect(w.Changed()).To(BeEmpty())
+	})
+})
+
+var _ = Describe("dependencies", func() {
+	It("provides included files and go packages", func() {
+		dir := GinkgoT().TempDir()
+		src := filepath.Join(dir, "src")
+		Expect(os.MkdirAll(src, 0o755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "data"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "data", "file"), []byte("data\n"), 0o644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(src, "doc.mdg"), []byte("{{include ../data/file}}\n{{godoc ../data}}\n{{include missing}}\n"), 0o644)).To(Succeed())
+
+		t, err := tree.ForFolderWithLogger(src, logging.Discard())
+		Expect(err).To(Succeed())
+		Expect(t.Resolve()).NotTo(Succeed())
+		Expect(t.Dependencies()).To(Equal([]string{
+			filepath.Join(dir, "data"),
+			filepath.Join(dir, "data", "file"),
+			filepath.Join(src, "missing"),
+		}))
+	})
+})
@@ -0,0 +1,125 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tree
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+const (
+	// WIKI_HOME is the page name of the start page of a wiki.
+	// It is used for the root document README.
+	WIKI_HOME = "Home"
+	// WIKI_SIDEBAR is the page name of the sidebar of a wiki.
+	WIKI_SIDEBAR = "_Sidebar"
+	// WIKI_FOOTER is the page name of the footer of a wiki.
+	WIKI_FOOTER = "_Footer"
+)
+
+// WikiPage provides the wiki page name used for a document.
+// Wiki pages are kept in a flat namespace, therefore the folders
+// of the ref path are joined with a dash. The root document README
+// is used as start page (WIKI_HOME).
+func WikiPage(refpath string) string {
+	page := strings.TrimPrefix(refpath, "/")
+	if page == "README" {
+		return WIKI_HOME
+	}
+	return strings.ReplaceAll(page, "/", "-")
+}
+
+// WikiTreeWriter generates the pages of a GitHub wiki instead of a
+// markdown tree. All documents are generated as flat list of pages
+// (see WikiPage) and links between documents refer to the page names.
+// If the source tree does not provide a sidebar document (_Sidebar.mdg)
+// the sidebar is generated from the section structure (see Navigation).
+// A footer can be provided by a source document _Footer.mdg.
+type WikiTreeWriter = *wikiTreeWriter
+
+type wikiTreeWriter struct {
+	*fileTreeWriter
+	nav   []NavEntry
+	pages map[string]string
+}
+
+var _ DocumentLinks = (*wikiTreeWriter)(nil)
+
+func NewWikiTreeWriter(path string, fss ...vfs.FileSystem) (WikiTreeWriter, error) {
+	w, err := NewFileTreeWriter(path, fss...)
+	if err != nil {
+		return nil, err
+	}
+	return &wikiTreeWriter{
+		fileTreeWriter: w,
+		pages:          map[string]string{},
+	}, nil
+}
+
+// SetNavigation sets the navigation structure used for the
+// generated sidebar.
+func (w *wikiTreeWriter) SetNavigation(nav []NavEntry) {
+	w.nav = nav
+}
+
+func (w *wikiTreeWriter) DocumentLink(refpath string) string {
+	return WikiPage(refpath)
+}
+
+func (w *wikiTreeWriter) Document(refpath string) (io.WriteCloser, string, error) {
+	page := WikiPage(refpath)
+	if old, ok := w.pages[page]; ok && old != refpath {
+		return nil, "", fmt.Errorf("documents %s and %s are mapped to the same wiki page %q", old, refpath, page)
+	}
+	w.pages[page] = refpath
+	return w.fileTreeWriter.Document("/" + page)
+}
+
+func (w *wikiTreeWriter) Close() error {
+	if _, ok := w.pages[WIKI_SIDEBAR]; !ok && len(w.nav) > 0 {
+		err := w.writeSidebar()
+		if err != nil {
+			return err
+		}
+	}
+	return w.fileTreeWriter.Close()
+}
+
+// writeSidebar generates the sidebar as nested list
+// of links to the sections of the wiki pages.
+func (w *wikiTreeWriter) writeSidebar() error {
+	d, _, err := w.fileTreeWriter.Document("/" + WIKI_SIDEBAR)
+	if err != nil {
+		return err
+	}
+	lvl := -1
+	for _, e := range w.nav {
+		page := WikiPage(e.RefPath)
+		if page == WIKI_SIDEBAR || page == WIKI_FOOTER {
+			continue
+		}
+		l := e.Level
+		if l > lvl+1 {
+			l = lvl + 1
+		}
+		lvl = l
+
+		link := page
+		if e.Anchor != "" {
+			link += "#" + e.Anchor
+		}
+		title := plainText(e.Title)
+		if e.Label != "" {
+			title = e.Label + " " + title
+		}
+		fmt.Fprintf(d, "%s- [%s](%s)\n", strings.Repeat("  ", l), title, link)
+	}
+	return d.Close()
+}
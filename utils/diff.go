@@ -0,0 +1,199 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+const DefaultDiffContext = 3
+
+type editOp byte
+
+const (
+	opEqual  editOp = ' '
+	opDelete editOp = '-'
+	opInsert editOp = '+'
+)
+
+type edit struct {
+	op   editOp
+	a, b int // line index in old and new text
+}
+
+// UnifiedDiff provides a unified diff for two texts using the given
+// amount of context lines. An empty string is returned, if both texts are
+// identical.
+func UnifiedDiff(oldname, newname string, old, new string, context int) string {
+	if old == new {
+		return ""
+	}
+	a := splitLines(old)
+	b := splitLines(new)
+	edits := diffLines(a, b)
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", oldname, newname)
+
+	for start := 0; start < len(edits); {
+		// find next change
+		for start < len(edits) && edits[start].op == opEqual {
+			start++
+		}
+		if start >= len(edits) {
+			break
+		}
+		first := start - context
+		if first < 0 {
+			first = 0
+		}
+		// extend hunk as long as changes are separated by at most 2*context equal lines
+		end := start
+		for end < len(edits) {
+			if edits[end].op != opEqual {
+				end++
+				continue
+			}
+			n := end
+			for n < len(edits) && edits[n].op == opEqual {
+				n++
+			}
+			if n >= len(edits) || n-end > 2*context {
+				end += context
+				if end > len(edits) {
+					end = len(edits)
+				}
+				break
+			}
+			end = n
+		}
+		writeHunk(&buf, a, b, edits[first:end])
+		start = end
+	}
+	return buf.String()
+}
+
+func writeHunk(buf *strings.Builder, a, b []string, edits []edit) {
+	astart, bstart := -1, -1
+	acnt, bcnt := 0, 0
+	for _, e := range edits {
+		if e.op != opInsert {
+			if astart < 0 {
+				astart = e.a
+			}
+			acnt++
+		}
+		if e.op != opDelete {
+			if bstart < 0 {
+				bstart = e.b
+			}
+			bcnt++
+		}
+	}
+	fmt.Fprintf(buf, "@@ -%s +%s @@\n", hunkRange(astart, acnt, edits[0].a), hunkRange(bstart, bcnt, edits[0].b))
+	for _, e := range edits {
+		line := ""
+		switch e.op {
+		case opInsert:
+			line = b[e.b]
+		default:
+			line = a[e.a]
+		}
+		buf.WriteByte(byte(e.op))
+		if strings.HasSuffix(line, "\n") {
+			buf.WriteString(line)
+		} else {
+			buf.WriteString(line + "\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start, cnt int, pos int) string {
+	if cnt == 0 {
+		return fmt.Sprintf("%d,0", pos)
+	}
+	if cnt == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, cnt)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines calculates a shortest edit script using the
+// algorithm of Eugene W. Myers.
+func diffLines(a, b []string) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	off := max + 1
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+	x, y := 0, 0
+outer:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && v[k-1+off] < v[k+1+off]) {
+				x = v[k+1+off]
+			} else {
+				x = v[k-1+off] + 1
+			}
+			y = x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+off] = x
+			if x >= n && y >= m {
+				break outer
+			}
+		}
+	}
+
+	var edits []edit
+	x, y = n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevk int
+		if k == -d || (k != d && v[k-1+off] < v[k+1+off]) {
+			prevk = k + 1
+		} else {
+			prevk = k - 1
+		}
+		prevx := v[prevk+off]
+		prevy := prevx - prevk
+		for x > prevx && y > prevy {
+			x--
+			y--
+			edits = append(edits, edit{opEqual, x, y})
+		}
+		if d > 0 {
+			if x == prevx {
+				edits = append(edits, edit{opInsert, x, y - 1})
+			} else {
+				edits = append(edits, edit{opDelete, x - 1, y})
+			}
+		}
+		x, y = prevx, prevy
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
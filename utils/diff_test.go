@@ -0,0 +1,84 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package utils
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("unified diff", func() {
+	It("identical", func() {
+		Expect(UnifiedDiff("a", "b", "a\nb\n", "a\nb\n", 3)).To(Equal(""))
+	})
+
+	It("changed line", func() {
+		Expect(UnifiedDiff("a", "b", "a\nb\nc\n", "a\nx\nc\n", 3)).To(Equal(`--- a
++++ b
+@@ -1,3 +1,3 @@
+ a
+-b
++x
+ c
+`))
+	})
+
+	It("added file", func() {
+		Expect(UnifiedDiff("/dev/null", "b", "", "a\nb\n", 3)).To(Equal(`--- /dev/null
++++ b
+@@ -0,0 +1,2 @@
++a
++b
+`))
+	})
+
+	It("removed file", func() {
+		Expect(UnifiedDiff("a", "/dev/null", "a\n", "", 3)).To(Equal(`--- a
++++ /dev/null
+@@ -1 +0,0 @@
+-a
+`))
+	})
+
+	It("separate hunks", func() {
+		Expect(UnifiedDiff("a", "b", "1\n2\n3\n4\n5\n6\n7\n8\n", "0\n2\n3\n4\n5\n6\n7\n9\n", 1)).To(Equal(`--- a
++++ b
+@@ -1,2 +1,2 @@
+-1
++0
+ 2
+@@ -7,2 +7,2 @@
+ 7
+-8
++9
+`))
+	})
+
+	It("joined hunks", func() {
+		Expect(UnifiedDiff("a", "b", "1\n2\n3\n4\n", "0\n2\n3\n5\n", 1)).To(Equal(`--- a
++++ b
+@@ -1,4 +1,4 @@
+-1
++0
+ 2
+ 3
+-4
++5
+`))
+	})
+
+	It("missing newline", func() {
+		Expect(UnifiedDiff("a", "b", "a\nb", "a\nb\n", 3)).To(Equal(`--- a
++++ b
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++b
+`))
+	})
+})
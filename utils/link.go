@@ -13,15 +13,39 @@ import (
 )
 
 type Link struct {
-	anchor string
-	path   string
-	tag    string
+	project string
+	anchor  string
+	path    string
+	tag     string
 }
 
 func (l Link) IsValid() bool {
 	return l.tag != "" || l.anchor != "" || l.path != ""
 }
 
+// NewExternalLink provides a link into the reference inventory of
+// another project imported under the given prefix.
+func NewExternalLink(project string, l Link) Link {
+	l.project = project
+	return l
+}
+
+// Project provides the prefix of the project an external link refers to.
+// It is empty for links into the actual document tree.
+func (l Link) Project() string {
+	return l.project
+}
+
+func (l Link) IsExternal() bool {
+	return l.project != ""
+}
+
+// Local provides the link without the project prefix.
+func (l Link) Local() Link {
+	l.project = ""
+	return l
+}
+
 func NewLink(path string, anchor string) Link {
 	return Link{anchor: anchor, path: path}
 }
@@ -50,6 +74,9 @@ func (l Link) Tag() string {
 }
 
 func (l Link) String() string {
+	if l.project != "" {
+		return l.project + ":" + l.Local().String()
+	}
 	if l.tag != "" {
 		return "#" + l.tag
 	}
@@ -62,7 +89,7 @@ func (l Link) String() string {
 func (l Link) Abs(base string, global bool) (Link, error) {
 	var r Link
 
-	if l.tag != "" {
+	if l.tag != "" || l.project != "" {
 		return l, nil
 	}
 	if path.IsAbs(l.path) {
@@ -95,9 +122,24 @@ func ParseAbsoluteLink(link string, base string, global bool) (Link, error) {
 	return l.Abs(base, global)
 }
 
+// ParseLink parses a link. A link into the reference inventory
+// of another project is prefixed by the project prefix followed by
+// a colon (<prefix>:<link>). It must use a tag or an absolute document path.
 func ParseLink(link string, asAnchor ...bool) (Link, error) {
 	var r Link
 
+	if project, local := SplitProjectPrefix(link); project != "" {
+		r, err := ParseLink(local, asAnchor...)
+		if err != nil {
+			return r, err
+		}
+		if r.tag == "" && !path.IsAbs(r.path) {
+			return r, fmt.Errorf("external link %q requires a tag or an absolute document path", link)
+		}
+		r.project = project
+		return r, nil
+	}
+
 	comps := strings.Split(link, "#")
 	if len(comps) > 2 {
 		return r, fmt.Errorf("invalid link target %q", link)
@@ -134,3 +176,31 @@ func ParseLink(link string, asAnchor ...bool) (Link, error) {
 	}
 	return r, nil
 }
+
+// IsURL checks whether a location is an http(s) URL
+// instead of a file path.
+func IsURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// SplitProjectPrefix splits an optional project prefix
+// (<prefix>:<name>) from a name. The prefix is empty if the
+// name does not refer to another project.
+func SplitProjectPrefix(s string) (string, string) {
+	if i := strings.Index(s, ":"); i > 0 && isProjectPrefix(s[:i]) {
+		return s[:i], s[i+1:]
+	}
+	return "", s
+}
+
+func isProjectPrefix(s string) bool {
+	for i, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case i > 0 && (c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
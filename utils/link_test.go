@@ -0,0 +1,47 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package utils
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("links", func() {
+	It("parses local links", func() {
+		l, err := ParseLink("#/statements")
+		Expect(err).To(Succeed())
+		Expect(l.IsTag()).To(BeTrue())
+		Expect(l.IsExternal()).To(BeFalse())
+		Expect(l.String()).To(Equal("#/statements"))
+
+		l, err = ParseLink("/syntax#anchor")
+		Expect(err).To(Succeed())
+		Expect(l.Path()).To(Equal("/syntax"))
+		Expect(l.Anchor()).To(Equal("anchor"))
+	})
+
+	It("parses external links", func() {
+		l, err := ParseLink("platform:#/statements")
+		Expect(err).To(Succeed())
+		Expect(l.Project()).To(Equal("platform"))
+		Expect(l.Tag()).To(Equal("/statements"))
+		Expect(l.Local()).To(Equal(NewTagLink("/statements")))
+		Expect(l.String()).To(Equal("platform:#/statements"))
+
+		l, err = ParseAbsoluteLink("platform:/syntax#anchor", "/doc", false)
+		Expect(err).To(Succeed())
+		Expect(l).To(Equal(NewExternalLink("platform", NewLink("/syntax", "anchor"))))
+	})
+
+	It("rejects relative external links", func() {
+		_, err := ParseLink("platform:#anchor")
+		Expect(err).To(MatchError(`external link "platform:#anchor" requires a tag or an absolute document path`))
+		_, err = ParseLink("platform:syntax")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,58 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package utils
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MatchPath matches a slash separated path against a pattern.
+// The pattern uses the syntax of path.Match for every path
+// component. Additionally, the component ** matches any number of
+// path components. A pattern without a slash matches the base name
+// of the path.
+func MatchPath(pattern, p string) bool {
+	p = strings.Trim(p, "/")
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(p))
+		return ok
+	}
+	return matchComponents(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(p, "/"))
+}
+
+func matchComponents(pattern, comps []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(comps); i++ {
+				if matchComponents(pattern[1:], comps[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(comps) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], comps[0]); !ok {
+			return false
+		}
+		pattern, comps = pattern[1:], comps[1:]
+	}
+	return len(comps) == 0
+}
+
+// CheckPathPattern checks a pattern used for MatchPath.
+func CheckPathPattern(pattern string) error {
+	for _, c := range strings.Split(pattern, "/") {
+		if _, err := path.Match(c, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
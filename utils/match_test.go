@@ -0,0 +1,38 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package utils
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("path patterns", func() {
+	It("matches base names", func() {
+		Expect(MatchPath("*.mdg", "a/b/c.mdg")).To(BeTrue())
+		Expect(MatchPath("draft*", "a/draft1.mdg")).To(BeTrue())
+		Expect(MatchPath("*.mdg", "a/b/c.md")).To(BeFalse())
+	})
+
+	It("matches paths", func() {
+		Expect(MatchPath("a/*.mdg", "a/c.mdg")).To(BeTrue())
+		Expect(MatchPath("a/*.mdg", "a/b/c.mdg")).To(BeFalse())
+		Expect(MatchPath("/a/*", "a/c.mdg")).To(BeTrue())
+	})
+
+	It("matches any number of components", func() {
+		Expect(MatchPath("a/**/c.mdg", "a/c.mdg")).To(BeTrue())
+		Expect(MatchPath("a/**/c.mdg", "a/b/d/c.mdg")).To(BeTrue())
+		Expect(MatchPath("**/drafts/**", "a/drafts/x.mdg")).To(BeTrue())
+		Expect(MatchPath("a/**", "b/c.mdg")).To(BeFalse())
+	})
+
+	It("checks patterns", func() {
+		Expect(CheckPathPattern("a/[")).To(HaveOccurred())
+		Expect(CheckPathPattern("a/**/*.mdg")).To(Succeed())
+	})
+})
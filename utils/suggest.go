@@ -0,0 +1,125 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package utils
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// MaxSuggestions is the maximum number of suggestions provided
+// by Suggest.
+const MaxSuggestions = 3
+
+// Levenshtein calculates the edit distance between two strings.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(a int, others ...int) int {
+	for _, o := range others {
+		if o < a {
+			a = o
+		}
+	}
+	return a
+}
+
+// Suggest provides the candidates closest to the given name. A candidate
+// is considered, if its edit distance is small compared to the length of
+// the name, or if it has the same base name in another namespace (path).
+// The best matches are returned, first.
+func Suggest(name string, candidates []string) []string {
+	type match struct {
+		name string
+		dist int
+	}
+
+	limit := len([]rune(name)) / 3
+	if limit < 2 {
+		limit = 2
+	}
+	base := baseName(name)
+
+	var matches []match
+	found := Set[string]{}
+	for _, c := range candidates {
+		if c == name || found.Has(c) {
+			continue
+		}
+		d := Levenshtein(name, c)
+		if d > limit {
+			if base == "" || baseName(c) != base {
+				continue
+			}
+			// same name in other namespace: rank after close matches
+			d = limit + 1
+		}
+		found.Add(c)
+		matches = append(matches, match{c, d})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].dist != matches[j].dist {
+			return matches[i].dist < matches[j].dist
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	var result []string
+	for i := 0; i < len(matches) && i < MaxSuggestions; i++ {
+		result = append(result, matches[i].name)
+	}
+	return result
+}
+
+// DidYouMean provides a hint describing the suggestions for
+// the given name. If there are no suggestions, an empty string is
+// returned. Otherwise, the hint starts with a space, so it can directly
+// be appended to an error message.
+func DidYouMean(name string, candidates []string) string {
+	list := Suggest(name, candidates)
+	if len(list) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	if len(quoted) == 1 {
+		return fmt.Sprintf(" (did you mean %s?)", quoted[0])
+	}
+	return fmt.Sprintf(" (did you mean %s or %s?)", strings.Join(quoted[:len(quoted)-1], ", "), quoted[len(quoted)-1])
+}
+
+func baseName(name string) string {
+	if i := strings.LastIndex(name, "#"); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" {
+		return ""
+	}
+	return path.Base(name)
+}
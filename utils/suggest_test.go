@@ -0,0 +1,39 @@
+/*
+ * SPDX-FileCopyrightText: 2023 Mandelsoft.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package utils
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("suggestions", func() {
+	It("calculates edit distance", func() {
+		Expect(Levenshtein("", "")).To(Equal(0))
+		Expect(Levenshtein("abc", "")).To(Equal(3))
+		Expect(Levenshtein("kitten", "sitting")).To(Equal(3))
+		Expect(Levenshtein("statments", "statements")).To(Equal(1))
+	})
+
+	It("suggests close matches", func() {
+		Expect(Suggest("#/statments", []string{"#/statements", "#/syntax", "#/statement"})).To(Equal([]string{"#/statements", "#/statement"}))
+	})
+
+	It("suggests same names in other namespaces", func() {
+		Expect(Suggest("#/other/section", []string{"#/statement/section", "#/statement/anchor"})).To(Equal([]string{"#/statement/section"}))
+	})
+
+	It("limits suggestions", func() {
+		Expect(Suggest("ab", []string{"a", "b", "abc", "abd", "xyz"})).To(Equal([]string{"a", "abc", "abd"}))
+	})
+
+	It("formats hints", func() {
+		Expect(DidYouMean("x", nil)).To(Equal(""))
+		Expect(DidYouMean("term", []string{"terms"})).To(Equal(` (did you mean "terms"?)`))
+		Expect(DidYouMean("term", []string{"terms", "team"})).To(Equal(` (did you mean "team" or "terms"?)`))
+	})
+})